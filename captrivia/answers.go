@@ -0,0 +1,191 @@
+package captrivia
+
+import (
+	"slices"
+	"time"
+)
+
+// AnswerRejection is the reason an answer was not accepted for scoring.
+type AnswerRejection string
+
+const (
+	AnswerRejectedNotOpen    AnswerRejection = "question_not_open" // no question is being displayed
+	AnswerRejectedStale      AnswerRejection = "stale_question"    // the answer is for a question other than the current one
+	AnswerRejectedDuplicate  AnswerRejection = "already_answered"  // the player has used up their answers to the question
+	AnswerRejectedNotPlayer  AnswerRejection = "not_player"        // the player doesn't have a seat in the game
+	AnswerRejectedEliminated AnswerRejection = "eliminated"        // the player is out of lives in a survival game
+)
+
+// AnswerRecord is an audit entry for an answer submitted by a player. Every
+// answer received while the game is running is recorded, including ones that
+// were not accepted for scoring.
+type AnswerRecord struct {
+	Player     string          `json:"player"`
+	QuestionID string          `json:"question_id"`
+	Index      int             `json:"index"`
+	Correct    bool            `json:"correct"`
+	Accepted   bool            `json:"accepted"`
+	Rejection  AnswerRejection `json:"rejection,omitempty"`
+	Points     int             `json:"points"`
+	LatencyMs  int64           `json:"latency_ms"`
+	AnsweredAt time.Time       `json:"answered_at"`
+}
+
+// AnswerQuestion validates and scores a player's answer to the question with
+// the ID, adding it to the game's answer log. Answers are only accepted for
+// the question being displayed, and only up to the game's answers per
+// question. answeredAt should be the time the answer was received by the
+// server.
+func (g *Game) AnswerQuestion(player string, questionID string, index int, answeredAt time.Time, questionDuration time.Duration) (AnswerRecord, AnswerOutcome) {
+	var outcome AnswerOutcome
+	correct := false
+	if outcome.Rejection = g.checkAnswer(player, questionID); outcome.Rejection == "" {
+		correct = g.ValidateAnswer(index)
+		outcome = g.ScoreAnswer(player, correct, answeredAt, questionDuration)
+		if !outcome.Accepted {
+			outcome.Rejection = AnswerRejectedDuplicate
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if outcome.Accepted && g.waitsForEveryone() {
+		outcome.CloseQuestion = g.everyoneAnswered()
+	}
+
+	record := AnswerRecord{
+		Player:     player,
+		QuestionID: questionID,
+		Index:      index,
+		Correct:    correct,
+		Accepted:   outcome.Accepted,
+		Rejection:  outcome.Rejection,
+		Points:     outcome.Points,
+		LatencyMs:  answeredAt.Sub(g.questionDisplayedAt).Milliseconds(),
+		AnsweredAt: answeredAt,
+	}
+	g.answers = append(g.answers, record)
+
+	return record, outcome
+}
+
+// checkAnswer returns why a player's answer to the question with the ID can't
+// be accepted, counting it against the player's answers to the question if it
+// can.
+func (g *Game) checkAnswer(player string, questionID string) AnswerRejection {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.State != GameStateQuestion {
+		return AnswerRejectedNotOpen
+	}
+	if questionID != g.questions[g.currentQuestionIndex].ID {
+		return AnswerRejectedStale
+	}
+	if g.isEliminated(player) {
+		return AnswerRejectedEliminated
+	}
+	if _, ok := g.PlayersReady[player]; !ok {
+		return AnswerRejectedNotPlayer
+	}
+	if g.answerCounts[player] >= g.answerLimit() {
+		return AnswerRejectedDuplicate
+	}
+	g.answerCounts[player]++
+	return ""
+}
+
+// everyoneAnswered reports whether every seated player still in the game has
+// answered the current question. g.mu must be held.
+func (g *Game) everyoneAnswered() bool {
+	for player := range g.PlayersReady {
+		if g.answerCounts[player] == 0 && !g.isEliminated(player) {
+			return false
+		}
+	}
+	return true
+}
+
+// answerLimit is the number of answers a player may give to each question.
+func (g *Game) answerLimit() int {
+	if g.AnswersPerQuestion > 0 {
+		return g.AnswersPerQuestion
+	}
+	return 1
+}
+
+// Answers returns a copy of the game's answer log in the order the answers
+// were received.
+func (g *Game) Answers() []AnswerRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	answers := make([]AnswerRecord, len(g.answers))
+	copy(answers, g.answers)
+	return answers
+}
+
+// PlayerStats returns the stats for every player that was shown a question or
+// had an answer accepted in the game, built from the questions shown and the
+// answer log. Each question answered counts once, with the time of the
+// player's last accepted answer to it.
+func (g *Game) PlayerStats() []PlayerStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	byPlayer := make(map[string]*PlayerStats)
+	var players []string
+	stats := func(player string) *PlayerStats {
+		s, ok := byPlayer[player]
+		if !ok {
+			s = &PlayerStats{PlayerName: player}
+			byPlayer[player] = s
+			players = append(players, player)
+		}
+		return s
+	}
+
+	type answered struct {
+		player     string
+		questionID string
+	}
+	latencies := make(map[answered]int64)
+	correct := make(map[answered]bool)
+	for _, a := range g.answers {
+		if !a.Accepted {
+			continue
+		}
+		stats(a.Player)
+		key := answered{a.Player, a.QuestionID}
+		latencies[key] = a.LatencyMs
+		correct[key] = correct[key] || a.Correct
+	}
+	for key, latency := range latencies {
+		s := byPlayer[key.player]
+		s.AnsweredQuestions++
+		if correct[key] {
+			s.CorrectQuestions++
+		}
+		s.TimeMilliseconds += latency
+	}
+
+	shown := make([]string, 0, len(g.questionsShown))
+	for player := range g.questionsShown {
+		shown = append(shown, player)
+	}
+	slices.Sort(shown)
+	for _, player := range shown {
+		stats(player).TotalQuestions = g.questionsShown[player]
+	}
+
+	result := make([]PlayerStats, 0, len(players))
+	for _, player := range players {
+		s := byPlayer[player]
+		// questions answered by a player seated part way through them are
+		// shown too
+		s.TotalQuestions = max(s.TotalQuestions, s.AnsweredQuestions)
+		result = append(result, *s)
+	}
+	return result
+}
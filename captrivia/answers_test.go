@@ -0,0 +1,116 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerQuestion(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	g.AnswersPerQuestion = 3
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+
+	displayed := time.Now()
+	g.QuestionDisplayed(displayed)
+
+	record, outcome := g.AnswerQuestion("player 1", q.ID, wrongIndex, displayed.Add(1*time.Second), 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.False(t, record.Correct)
+	assert.Equal(t, q.ID, record.QuestionID)
+	assert.Equal(t, int64(1000), record.LatencyMs)
+
+	record, outcome = g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed.Add(2*time.Second), 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.True(t, record.Correct)
+	assert.Equal(t, 1, record.Points)
+
+	// answers after a correct one are logged but not accepted
+	record, outcome = g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed.Add(3*time.Second), 5*time.Second)
+	assert.False(t, outcome.Accepted)
+	assert.False(t, record.Accepted)
+	assert.Equal(t, captrivia.AnswerRejectedDuplicate, record.Rejection)
+
+	answers := g.Answers()
+	assert.Equal(t, 3, len(answers))
+	assert.Equal(t, "player 1", answers[0].Player)
+	assert.Equal(t, wrongIndex, answers[0].Index)
+}
+
+func TestPlayerStatsFromAnswers(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	g.AnswersPerQuestion = 2
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+
+	displayed := time.Now()
+	g.QuestionDisplayed(displayed)
+	g.AnswerQuestion("player 1", q.ID, wrongIndex, displayed.Add(1*time.Second), 5*time.Second)
+	g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed.Add(2*time.Second), 5*time.Second)
+	g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed.Add(3*time.Second), 5*time.Second)
+
+	// the next question is shown but nobody answers it
+	g.GoToNextQuestion()
+	g.QuestionDisplayed(displayed)
+
+	stats := g.PlayerStats()
+	assert.Equal(t, []captrivia.PlayerStats{
+		{PlayerName: "player 1", CorrectQuestions: 1, AnsweredQuestions: 1, TotalQuestions: 2, TimeMilliseconds: 2000},
+		{PlayerName: "player 2", TotalQuestions: 2},
+	}, stats)
+
+	restored, err := captrivia.RestoreGame(g.Snapshot())
+	assert.NoError(t, err)
+	assert.Equal(t, stats, restored.PlayerStats())
+}
+
+func TestAnswerQuestionRejections(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+	displayed := time.Now()
+	g.QuestionDisplayed(displayed)
+
+	// answers sent between questions are not accepted
+	_, outcome := g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed, 5*time.Second)
+	assert.Equal(t, captrivia.AnswerRejectedNotOpen, outcome.Rejection)
+
+	g.State = captrivia.GameStateQuestion
+	_, outcome = g.AnswerQuestion("player 1", "previous question", q.CorrectIndex, displayed, 5*time.Second)
+	assert.False(t, outcome.Accepted)
+	assert.Equal(t, captrivia.AnswerRejectedStale, outcome.Rejection)
+
+	_, outcome = g.AnswerQuestion("spectator", q.ID, q.CorrectIndex, displayed, 5*time.Second)
+	assert.Equal(t, captrivia.AnswerRejectedNotPlayer, outcome.Rejection)
+
+	// players get one answer per question by default
+	_, outcome = g.AnswerQuestion("player 1", q.ID, wrongIndex, displayed, 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	_, outcome = g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, displayed, 5*time.Second)
+	assert.Equal(t, captrivia.AnswerRejectedDuplicate, outcome.Rejection)
+	assert.Equal(t, 0, g.Scores["player 1"])
+
+	// the answer count is per question
+	_, outcome = g.AnswerQuestion("player 2", q.ID, wrongIndex, displayed, 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	g.GoToNextQuestion()
+	next := g.CurrentQuestion()
+	_, outcome = g.AnswerQuestion("player 1", next.ID, next.CorrectIndex, displayed, 5*time.Second)
+	assert.True(t, outcome.Accepted)
+
+	assert.Len(t, g.Answers(), 7)
+}
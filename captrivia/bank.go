@@ -0,0 +1,247 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+var ErrNotEnoughQuestions = errors.New("not enough questions match the filter")
+
+// QuestionFilter narrows down the questions drawn for a game. An empty filter
+// matches every question.
+type QuestionFilter struct {
+	Categories []string `json:"categories,omitempty"`
+	// DifficultyMix is how many questions of each difficulty to draw. When it
+	// is set the counts must add up to the number of questions drawn.
+	DifficultyMix map[Difficulty]int `json:"difficulty_mix,omitempty"`
+}
+
+// QuestionBank supplies the questions used for games. Draw also returns the
+// version of the bank the questions were drawn from. DrawSeeded draws the same
+// questions every time it is given the same seed, as long as the bank's
+// questions are unchanged. Lookup returns the questions with the ids, in the
+// order of the ids, skipping any no longer in the bank.
+type QuestionBank interface {
+	Draw(n int, filter QuestionFilter) ([]Question, string, error)
+	DrawSeeded(n int, filter QuestionFilter, seed int64) ([]Question, string, error)
+	Lookup(ids []string) ([]Question, string, error)
+	Version() (string, error)
+}
+
+// MemoryQuestionBank is a QuestionBank holding every question in memory.
+type MemoryQuestionBank struct {
+	questions []Question
+	version   string
+}
+
+func NewMemoryQuestionBank(questions []Question) *MemoryQuestionBank {
+	return &MemoryQuestionBank{questions: questions}
+}
+
+// LoadQuestionBank reads the questions file once and returns a bank serving
+// its questions. The file is rejected if any question is invalid.
+func LoadQuestionBank(filename string) (*MemoryQuestionBank, error) {
+	questions, err := LoadQuestions(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateQuestions(questions); err != nil {
+		return nil, err
+	}
+	return NewMemoryQuestionBank(questions), nil
+}
+
+func (b *MemoryQuestionBank) Questions() []Question {
+	return b.questions
+}
+
+func (b *MemoryQuestionBank) Version() (string, error) {
+	return b.version, nil
+}
+
+// Draw returns n random questions matching the filter.
+func (b *MemoryQuestionBank) Draw(n int, filter QuestionFilter) ([]Question, string, error) {
+	questions, err := b.draw(n, filter, nil)
+	return questions, b.version, err
+}
+
+// DrawSeeded returns n questions matching the filter, picked at random with
+// the seed.
+func (b *MemoryQuestionBank) DrawSeeded(n int, filter QuestionFilter, seed int64) ([]Question, string, error) {
+	questions, err := b.draw(n, filter, rand.New(rand.NewSource(seed)))
+	return questions, b.version, err
+}
+
+// Lookup returns the bank's questions with the ids, in the order of the ids.
+func (b *MemoryQuestionBank) Lookup(ids []string) ([]Question, string, error) {
+	byID := make(map[string]Question, len(b.questions))
+	for _, q := range b.questions {
+		byID[q.ID] = q
+	}
+
+	questions := make([]Question, 0, len(ids))
+	for _, id := range ids {
+		if q, ok := byID[id]; ok {
+			questions = append(questions, q)
+		}
+	}
+	return questions, b.version, nil
+}
+
+// draw picks the questions with rng, or with the global source when rng is
+// nil.
+func (b *MemoryQuestionBank) draw(n int, filter QuestionFilter, rng *rand.Rand) ([]Question, error) {
+	if err := filter.Validate(n); err != nil {
+		return nil, err
+	}
+
+	pool := filter.apply(b.questions)
+	if rng != nil {
+		// the bank's questions may be loaded in any order, a seed only picks
+		// the same questions from them once they are in a fixed order
+		slices.SortFunc(pool, func(a, b Question) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+	if len(filter.DifficultyMix) == 0 {
+		if len(pool) < n {
+			return nil, fmt.Errorf("%w: wanted %d, found %d", ErrNotEnoughQuestions, n, len(pool))
+		}
+		return shuffleQuestions(pool, n, rng), nil
+	}
+
+	drawn := make([]Question, 0, n)
+	// difficulties are drawn in a fixed order so a seed always draws the same
+	// questions
+	difficulties := make([]Difficulty, 0, len(filter.DifficultyMix))
+	for difficulty := range filter.DifficultyMix {
+		difficulties = append(difficulties, difficulty)
+	}
+	slices.Sort(difficulties)
+	for _, difficulty := range difficulties {
+		count := filter.DifficultyMix[difficulty]
+		var difficultyPool []Question
+		for _, q := range pool {
+			if q.Difficulty == difficulty {
+				difficultyPool = append(difficultyPool, q)
+			}
+		}
+		if len(difficultyPool) < count {
+			return nil, fmt.Errorf("%w: wanted %d %s, found %d", ErrNotEnoughQuestions, count, difficulty, len(difficultyPool))
+		}
+		drawn = append(drawn, shuffleQuestions(difficultyPool, count, rng)...)
+	}
+	swap := func(i, j int) {
+		drawn[i], drawn[j] = drawn[j], drawn[i]
+	}
+	if rng != nil {
+		rng.Shuffle(len(drawn), swap)
+	} else {
+		rand.Shuffle(len(drawn), swap)
+	}
+
+	return drawn, nil
+}
+
+// ServiceQuestionBank is a QuestionBank that draws from the questions held by
+// a QuestionService, so changes made through the admin API or a reload are
+// picked up by the next game created. The questions are cached in memory and
+// swapped out whenever the stored version changes.
+type ServiceQuestionBank struct {
+	service QuestionService
+	cached  atomic.Pointer[MemoryQuestionBank]
+}
+
+func NewServiceQuestionBank(service QuestionService) *ServiceQuestionBank {
+	return &ServiceQuestionBank{service: service}
+}
+
+func (b *ServiceQuestionBank) Version() (string, error) {
+	return b.service.GetQuestionsVersion()
+}
+
+func (b *ServiceQuestionBank) Draw(n int, filter QuestionFilter) ([]Question, string, error) {
+	bank, err := b.bank()
+	if err != nil {
+		return nil, "", err
+	}
+	return bank.Draw(n, filter)
+}
+
+func (b *ServiceQuestionBank) DrawSeeded(n int, filter QuestionFilter, seed int64) ([]Question, string, error) {
+	bank, err := b.bank()
+	if err != nil {
+		return nil, "", err
+	}
+	return bank.DrawSeeded(n, filter, seed)
+}
+
+func (b *ServiceQuestionBank) Lookup(ids []string) ([]Question, string, error) {
+	bank, err := b.bank()
+	if err != nil {
+		return nil, "", err
+	}
+	return bank.Lookup(ids)
+}
+
+// bank returns the cached questions, loading them again if the stored version
+// has changed.
+func (b *ServiceQuestionBank) bank() (*MemoryQuestionBank, error) {
+	version, err := b.service.GetQuestionsVersion()
+	if err != nil {
+		return nil, fmt.Errorf("error getting questions version: %w", err)
+	}
+
+	bank := b.cached.Load()
+	if bank == nil || bank.version != version {
+		version, questions, err := b.service.GetQuestionSet()
+		if err != nil {
+			return nil, fmt.Errorf("error getting questions: %w", err)
+		}
+		bank = &MemoryQuestionBank{questions: questions, version: version}
+		b.cached.Store(bank)
+	}
+	return bank, nil
+}
+
+// Validate checks the filter can be used to draw n questions.
+func (f QuestionFilter) Validate(n int) error {
+	if n < 1 {
+		return fmt.Errorf("question count must be at least 1")
+	}
+	if len(f.DifficultyMix) == 0 {
+		return nil
+	}
+
+	total := 0
+	for difficulty, count := range f.DifficultyMix {
+		if err := difficulty.Validate(); err != nil {
+			return err
+		}
+		if count < 0 {
+			return fmt.Errorf("difficulty mix count for %s can not be negative", difficulty)
+		}
+		total += count
+	}
+	if total != n {
+		return fmt.Errorf("difficulty mix adds up to %d questions, expected %d", total, n)
+	}
+	return nil
+}
+
+func (f QuestionFilter) apply(questions []Question) []Question {
+	var pool []Question
+	for _, q := range questions {
+		if q.Retired {
+			continue
+		}
+		if len(f.Categories) == 0 || slices.Contains(f.Categories, q.Category) {
+			pool = append(pool, q)
+		}
+	}
+	return pool
+}
@@ -0,0 +1,128 @@
+package captrivia_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBank() *captrivia.MemoryQuestionBank {
+	return captrivia.NewMemoryQuestionBank([]captrivia.Question{
+		{ID: "1", Category: "equity", Difficulty: captrivia.DifficultyEasy},
+		{ID: "2", Category: "equity", Difficulty: captrivia.DifficultyHard},
+		{ID: "3", Category: "equity", Difficulty: captrivia.DifficultyHard},
+		{ID: "4", Category: "fundraising", Difficulty: captrivia.DifficultyEasy},
+		{ID: "5", Category: "fundraising", Difficulty: captrivia.DifficultyMedium},
+	})
+}
+
+func TestDrawCategories(t *testing.T) {
+	bank := newTestBank()
+
+	questions, _, err := bank.Draw(3, captrivia.QuestionFilter{Categories: []string{"equity"}})
+	assert.Nil(t, err)
+	assert.Len(t, questions, 3)
+	for _, q := range questions {
+		assert.Equal(t, "equity", q.Category)
+	}
+
+	_, _, err = bank.Draw(4, captrivia.QuestionFilter{Categories: []string{"equity"}})
+	assert.ErrorIs(t, err, captrivia.ErrNotEnoughQuestions)
+}
+
+func TestDrawDifficultyMix(t *testing.T) {
+	bank := newTestBank()
+
+	filter := captrivia.QuestionFilter{
+		DifficultyMix: map[captrivia.Difficulty]int{
+			captrivia.DifficultyEasy: 2,
+			captrivia.DifficultyHard: 1,
+		},
+	}
+	questions, _, err := bank.Draw(3, filter)
+	assert.Nil(t, err)
+
+	counts := map[captrivia.Difficulty]int{}
+	for _, q := range questions {
+		counts[q.Difficulty]++
+	}
+	assert.Equal(t, filter.DifficultyMix, counts)
+
+	filter.Categories = []string{"fundraising"}
+	_, _, err = bank.Draw(3, filter)
+	assert.ErrorIs(t, err, captrivia.ErrNotEnoughQuestions)
+}
+
+func TestQuestionFilterValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		filter captrivia.QuestionFilter
+		valid  bool
+	}{
+		{"empty filter", 3, captrivia.QuestionFilter{}, true},
+		{"no questions", 0, captrivia.QuestionFilter{}, false},
+		{"mix matches count", 3, captrivia.QuestionFilter{DifficultyMix: map[captrivia.Difficulty]int{"easy": 1, "medium": 2}}, true},
+		{"mix short of count", 3, captrivia.QuestionFilter{DifficultyMix: map[captrivia.Difficulty]int{"easy": 1}}, false},
+		{"unknown difficulty", 1, captrivia.QuestionFilter{DifficultyMix: map[captrivia.Difficulty]int{"expert": 1}}, false},
+		{"negative count", 1, captrivia.QuestionFilter{DifficultyMix: map[captrivia.Difficulty]int{"easy": 2, "hard": -1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate(tt.n)
+			assert.Equal(t, tt.valid, err == nil, err)
+		})
+	}
+}
+
+func TestDrawSkipsRetired(t *testing.T) {
+	bank := captrivia.NewMemoryQuestionBank([]captrivia.Question{
+		{ID: "1"},
+		{ID: "2", Retired: true},
+	})
+
+	questions, _, err := bank.Draw(1, captrivia.QuestionFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", questions[0].ID)
+
+	_, _, err = bank.Draw(2, captrivia.QuestionFilter{})
+	assert.ErrorIs(t, err, captrivia.ErrNotEnoughQuestions)
+}
+
+func TestDrawSeeded(t *testing.T) {
+	bank := newTestBank()
+	questions := slices.Clone(bank.Questions())
+	slices.Reverse(questions)
+	reversed := captrivia.NewMemoryQuestionBank(questions)
+
+	ids := func(questions []captrivia.Question) []string {
+		var ids []string
+		for _, q := range questions {
+			ids = append(ids, q.ID)
+		}
+		return ids
+	}
+
+	// the same seed draws the same questions whatever order the bank holds
+	// them in
+	first, _, err := bank.DrawSeeded(3, captrivia.QuestionFilter{}, 42)
+	assert.NoError(t, err)
+	second, _, err := reversed.DrawSeeded(3, captrivia.QuestionFilter{}, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, ids(first), ids(second))
+
+	filter := captrivia.QuestionFilter{
+		DifficultyMix: map[captrivia.Difficulty]int{
+			captrivia.DifficultyEasy: 1,
+			captrivia.DifficultyHard: 2,
+		},
+	}
+	first, _, err = bank.DrawSeeded(3, filter, 7)
+	assert.NoError(t, err)
+	second, _, err = reversed.DrawSeeded(3, filter, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, ids(first), ids(second))
+}
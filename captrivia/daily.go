@@ -0,0 +1,194 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"time"
+)
+
+// DailyQuestionCount is the number of questions in each day's challenge.
+const DailyQuestionCount = 10
+
+var (
+	ErrDailyPlayed     = errors.New("player has already played the daily challenge")
+	ErrDailyNotStarted = errors.New("no one has started the daily challenge")
+)
+
+// DailyQuestionPins keeps the questions of each day's challenge, so the
+// challenge stays the same if the question bank changes during the day.
+type DailyQuestionPins interface {
+	// PinDailyQuestions keeps the ids as the questions of the date's challenge
+	// unless it already has some, and returns the ids the challenge keeps.
+	PinDailyQuestions(date string, ids []string) ([]string, error)
+	// GetDailyQuestions returns the ids pinned as the questions of the date's
+	// challenge, false if none have been pinned yet.
+	GetDailyQuestions(date string) ([]string, bool, error)
+}
+
+// DailyChallenge describes the challenge of a day without giving away its
+// questions. Every player of the day's challenge answers the same questions.
+type DailyChallenge struct {
+	Date          string   `json:"date"`
+	QuestionCount int      `json:"question_count"`
+	Categories    []string `json:"categories"`   // categories the day's questions are drawn from
+	BankVersion   string   `json:"bank_version"` // version of the bank the day's questions were read from
+}
+
+// DailyResult is how a player did in the challenge of a day. Players are
+// ranked by the questions they got right, then by how quickly they answered.
+type DailyResult struct {
+	Date             string    `json:"date"`
+	Rank             int       `json:"rank,omitempty"` // only set on leaderboard entries
+	PlayerName       string    `json:"player_name"`
+	Correct          int       `json:"correct"`
+	Answered         int       `json:"answered"`
+	QuestionCount    int       `json:"question_count"`
+	TimeMilliseconds int64     `json:"time_milliseconds"` // total time taken to answer
+	CompletedAt      time.Time `json:"completed_at"`
+}
+
+// DailyDate returns the date of the challenge being played at t, days change
+// over at midnight UTC.
+func DailyDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// ParseDailyDate checks a date asked for by a player is one a challenge has
+// been played on, returning it in the form DailyDate does.
+func ParseDailyDate(date string, now time.Time) (string, error) {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", fmt.Errorf("date must be formatted as YYYY-MM-DD")
+	}
+	if t.After(now.UTC()) {
+		return "", fmt.Errorf("the daily challenge for %s has not started", date)
+	}
+	return DailyDate(t), nil
+}
+
+// DailySeed is the seed the questions of the date's challenge are drawn with.
+func DailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("daily:" + date))
+	return int64(h.Sum64())
+}
+
+// drawDaily returns the questions of the date's challenge. They are drawn with
+// the date's seed the first time and pinned, later draws read the pinned
+// questions back from the bank.
+func drawDaily(date string, bank QuestionBank, pins DailyQuestionPins) ([]Question, string, error) {
+	questions, version, err := bank.DrawSeeded(DailyQuestionCount, QuestionFilter{}, DailySeed(date))
+	if err != nil {
+		return nil, "", fmt.Errorf("error drawing daily questions: %w", err)
+	}
+
+	ids := make([]string, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	pinned, err := pins.PinDailyQuestions(date, ids)
+	if err != nil {
+		return nil, "", fmt.Errorf("error pinning daily questions: %w", err)
+	}
+	if slices.Equal(pinned, ids) {
+		return questions, version, nil
+	}
+	return lookupDaily(date, bank, pinned)
+}
+
+// lookupDaily reads the questions pinned for the date's challenge from the
+// bank.
+func lookupDaily(date string, bank QuestionBank, ids []string) ([]Question, string, error) {
+	questions, version, err := bank.Lookup(ids)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading pinned daily questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, "", fmt.Errorf("%w: none of the daily questions for %s are left in the bank", ErrNotEnoughQuestions, date)
+	}
+	return questions, version, nil
+}
+
+// NewDailyChallenge reads the questions pinned for the date's challenge from
+// the bank to describe it. Describing a challenge doesn't pin its questions,
+// ErrDailyNotStarted is returned until the first player starts it.
+func NewDailyChallenge(date string, bank QuestionBank, pins DailyQuestionPins) (DailyChallenge, error) {
+	ids, ok, err := pins.GetDailyQuestions(date)
+	if err != nil {
+		return DailyChallenge{}, fmt.Errorf("error getting pinned daily questions: %w", err)
+	}
+	if !ok {
+		return DailyChallenge{}, fmt.Errorf("%w for %s", ErrDailyNotStarted, date)
+	}
+	questions, version, err := lookupDaily(date, bank, ids)
+	if err != nil {
+		return DailyChallenge{}, err
+	}
+
+	categories := []string{}
+	for _, q := range questions {
+		if q.Category != "" && !slices.Contains(categories, q.Category) {
+			categories = append(categories, q.Category)
+		}
+	}
+	slices.Sort(categories)
+
+	return DailyChallenge{
+		Date:          date,
+		QuestionCount: len(questions),
+		Categories:    categories,
+		BankVersion:   version,
+	}, nil
+}
+
+// NewDailyGame creates a practice game played with the questions of the
+// date's challenge.
+func NewDailyGame(name string, date string, bank QuestionBank, pins DailyQuestionPins) (*Game, error) {
+	questions, version, err := drawDaily(date, bank, pins)
+	if err != nil {
+		return nil, err
+	}
+
+	game := newGame(name, len(questions))
+	game.questions = questions
+	game.BankVersion = version
+	if err := game.ApplySettings(GameSettings{QuestionCount: len(questions)}.Practice()); err != nil {
+		return nil, err
+	}
+	game.Daily = date
+	return game, nil
+}
+
+// DailyResult returns how the player has done in the game's daily challenge,
+// built from the answer log.
+func (g *Game) DailyResult(player string, completedAt time.Time) DailyResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := DailyResult{
+		Date:          g.Daily,
+		PlayerName:    player,
+		QuestionCount: len(g.questions),
+		CompletedAt:   completedAt,
+	}
+	for _, a := range g.answers {
+		if a.Player != player || !a.Accepted {
+			continue
+		}
+		result.Answered++
+		if a.Correct {
+			result.Correct++
+		}
+		result.TimeMilliseconds += a.LatencyMs
+	}
+	return result
+}
+
+// RankScore orders results from best to worst when sorted highest first, the
+// questions answered correctly count before the time taken.
+func (r DailyResult) RankScore() float64 {
+	const maxMilliseconds = 1e10
+	return float64(r.Correct)*maxMilliseconds - min(float64(r.TimeMilliseconds), maxMilliseconds-1)
+}
@@ -0,0 +1,110 @@
+package captrivia_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyGame(t *testing.T) {
+	date := "2024-05-01"
+	pins := dailyPins{}
+
+	// the challenge can't be described before anyone has started it
+	_, err := captrivia.NewDailyChallenge(date, testBank, pins)
+	assert.ErrorIs(t, err, captrivia.ErrDailyNotStarted)
+	assert.Empty(t, pins)
+
+	a, err := captrivia.NewDailyGame("a", date, testBank, pins)
+	assert.NoError(t, err)
+	b, err := captrivia.NewDailyGame("b", date, testBank, pins)
+	assert.NoError(t, err)
+
+	// every player of the day's challenge gets the same questions
+	assert.Equal(t, a.Snapshot().Questions, b.Snapshot().Questions)
+	assert.Len(t, a.Snapshot().Questions, captrivia.DailyQuestionCount)
+	assert.Equal(t, date, a.Daily)
+	assert.True(t, a.IsPractice())
+
+	challenge, err := captrivia.NewDailyChallenge(date, testBank, pins)
+	assert.NoError(t, err)
+	assert.Equal(t, date, challenge.Date)
+	assert.Equal(t, captrivia.DailyQuestionCount, challenge.QuestionCount)
+
+	a.AddPlayer("a")
+	a.State = captrivia.GameStateQuestion
+	now := time.Now()
+	a.QuestionDisplayed(now)
+	q := a.CurrentQuestion()
+	a.AnswerQuestion("a", q.ID, q.CorrectIndex, now.Add(1500*time.Millisecond), 0)
+
+	result := a.DailyResult("a", now)
+	assert.Equal(t, captrivia.DailyResult{
+		Date:             date,
+		PlayerName:       "a",
+		Correct:          1,
+		Answered:         1,
+		QuestionCount:    captrivia.DailyQuestionCount,
+		TimeMilliseconds: 1500,
+		CompletedAt:      now,
+	}, result)
+}
+
+func TestParseDailyDate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	date, err := captrivia.ParseDailyDate("2024-04-30", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-04-30", date)
+
+	_, err = captrivia.ParseDailyDate("2024-05-02", now)
+	assert.Error(t, err)
+	_, err = captrivia.ParseDailyDate("yesterday", now)
+	assert.Error(t, err)
+}
+
+// dailyPins keeps the first questions pinned for each date.
+type dailyPins map[string][]string
+
+func (p dailyPins) PinDailyQuestions(date string, ids []string) ([]string, error) {
+	if pinned, ok := p[date]; ok {
+		return pinned, nil
+	}
+	p[date] = ids
+	return ids, nil
+}
+
+func (p dailyPins) GetDailyQuestions(date string) ([]string, bool, error) {
+	pinned, ok := p[date]
+	return pinned, ok, nil
+}
+
+func TestDailyGamePinsQuestions(t *testing.T) {
+	date := "2024-05-01"
+	pins := dailyPins{}
+	first, err := captrivia.NewDailyGame("a", date, testBank, pins)
+	assert.NoError(t, err)
+
+	// questions added to the bank during the day would change a seeded draw,
+	// the day's challenge keeps the questions it was first drawn with
+	questions := slices.Clone(testBank.Questions())
+	for i, q := range questions {
+		q.ID = fmt.Sprintf("added-%d", i)
+		questions = append(questions, q)
+	}
+	changed := captrivia.NewMemoryQuestionBank(questions)
+	drawn, _, err := changed.DrawSeeded(captrivia.DailyQuestionCount, captrivia.QuestionFilter{}, captrivia.DailySeed(date))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Snapshot().Questions, drawn)
+
+	second, err := captrivia.NewDailyGame("b", date, changed, pins)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Snapshot().Questions, second.Snapshot().Questions)
+
+	_, err = captrivia.NewDailyGame("c", date, captrivia.NewMemoryQuestionBank(questions[len(questions)/2:]), pins)
+	assert.ErrorIs(t, err, captrivia.ErrNotEnoughQuestions)
+}
@@ -2,23 +2,34 @@ package captrivia
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrGameStarted     = errors.New("game has already started")
+	ErrPlayerNotInGame = errors.New("player is not in the game")
+	ErrGameFull        = errors.New("game is full")
+)
+
 type GameState string
 
 const (
 	GameStateWaiting   GameState = "waiting"
 	GameStateCountdown GameState = "countdown"
 	GameStateQuestion  GameState = "question"
+	GameStateReveal    GameState = "reveal"
+	GameStatePaused    GameState = "paused"
 	GameStateEnded     GameState = "ended"
+	GameStateAborted   GameState = "aborted"
 )
 
 type Game struct {
@@ -27,11 +38,50 @@ type Game struct {
 	PlayersReady  map[string]bool `json:"players_ready"`
 	PlayerCount   int             `json:"player_count"`
 	QuestionCount int             `json:"question_count"`
-	State         GameState       `json:"state"`
+	ScoringMode   ScoringMode     `json:"scoring_mode"`
+	StartPolicy   StartPolicy     `json:"start_policy"`
+	// seconds each question, the countdown before it and the reveal of its
+	// answer are displayed for, no reveal phase is run when RevealSeconds is
+	// zero or NoReveal
+	QuestionSeconds  int `json:"question_seconds"`
+	CountdownSeconds int `json:"countdown_seconds"`
+	RevealSeconds    int `json:"reveal_seconds"`
+	MaxPlayers       int `json:"max_players"`
+	// answers each player may give to a question, zero allows one answer
+	AnswersPerQuestion int         `json:"answers_per_question"`
+	Categories         []string    `json:"categories,omitempty"`
+	Teams              []string    `json:"teams,omitempty"` // players play for these teams, games without teams are played individually
+	Mode               GameMode    `json:"mode"`
+	Lives              int         `json:"lives,omitempty"`  // lives each player starts a survival game with
+	Ranked             bool        `json:"ranked,omitempty"` // practice games only count towards the leaderboard when ranked
+	Daily              string      `json:"daily,omitempty"`  // date of the daily challenge a practice game is played with
+	TeamScoring        TeamScoring `json:"team_scoring,omitempty"`
+	Private            bool        `json:"private"`
+	InviteCode         string      `json:"invite_code,omitempty"` // private games are only joined with their invite code
+	BankVersion        string      `json:"bank_version"`          // version of the question bank the questions were drawn from
+	Host               string      `json:"host"`
+	State              GameState   `json:"state"`
 
 	currentQuestionIndex int
+	joinOrder            []string // seated players, the host is handed to the longest seated player when they leave
+	passwordHash         string
+	spectators           map[string]bool // watch the game without a seat, they can't answer
 	questions            []Question
-	Scores               map[string]int
+	questionDisplayedAt  time.Time
+	deadline             time.Time // when the current countdown or question ends
+	pausedFrom           GameState // state a paused game returns to when it is resumed
+	pausedAt             time.Time
+	Scores               map[string]int `json:"scores"`
+	answers              []AnswerRecord
+	scoring              ScoringStrategy
+	streaks              map[string]int
+	correctPlayers       map[string]bool // players that answered the current question correctly
+	answerCounts         map[string]int  // answers each player has given to the current question
+	playerTeams          map[string]string
+	teamScores           map[string]int
+	teamsScored          map[string]bool // teams that have scored the current question
+	livesLost            map[string]int
+	questionsShown       map[string]int // questions each seated player has been shown
 	gameEnded            chan bool
 	mu                   sync.Mutex
 }
@@ -44,7 +94,21 @@ type PlayerScore struct {
 type GameService interface {
 	SaveGame(g *Game) error
 	GetGames() ([]RepositoryGame, error)
+	GetGameSnapshots() ([]GameSnapshot, error)
 	ExpireGame(id uuid.UUID) error
+	SetGameOwner(id uuid.UUID, node string) error
+	GetGameOwner(id uuid.UUID) (string, error)
+	ReserveInviteCode(code string, id uuid.UUID) (bool, error)
+	GetInviteCodeGame(code string) (uuid.UUID, error)
+	SaveAnswerLog(gameID uuid.UUID, answers []AnswerRecord) error
+	GetAnswerLog(gameID uuid.UUID) ([]AnswerRecord, error)
+	UpdatePlayerStats(stats []PlayerStats) error
+	GetLeaderboard(q LeaderboardQuery) ([]PlayerStats, error)
+	SaveDailyResult(result DailyResult) error
+	ReserveDaily(date string, player string) (bool, error)
+	PinDailyQuestions(date string, ids []string) ([]string, error)
+	GetDailyQuestions(date string) ([]string, bool, error)
+	GetDailyLeaderboard(date string, offset int, limit int) ([]DailyResult, error)
 }
 
 func (g Game) MarshalJSON() ([]byte, error) {
@@ -68,87 +132,211 @@ func (g Game) PlayerNames() []string {
 }
 
 type RepositoryGame struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	PlayerCount   int       `json:"player_count"`
-	QuestionCount int       `json:"question_count"`
-	State         GameState `json:"state"`
+	ID               uuid.UUID   `json:"id"`
+	Name             string      `json:"name"`
+	PlayerCount      int         `json:"player_count"`
+	QuestionCount    int         `json:"question_count"`
+	Mode             GameMode    `json:"mode"`
+	QuestionSeconds  int         `json:"question_seconds"`
+	CountdownSeconds int         `json:"countdown_seconds"`
+	RevealSeconds    int         `json:"reveal_seconds"`
+	MaxPlayers       int         `json:"max_players"`
+	ScoringMode      ScoringMode `json:"scoring_mode"`
+	Categories       []string    `json:"categories"`
+	Private          bool        `json:"private"`
+	HasPassword      bool        `json:"has_password"`
+	State            GameState   `json:"state"`
 }
 
 func (g Game) ToRepositoryGame() RepositoryGame {
 	return RepositoryGame{
-		ID:            g.ID,
-		Name:          g.Name,
-		PlayerCount:   g.PlayerCount,
-		QuestionCount: g.QuestionCount,
-		State:         g.State,
+		ID:               g.ID,
+		Name:             g.Name,
+		PlayerCount:      g.PlayerCount,
+		QuestionCount:    g.QuestionCount,
+		Mode:             g.Mode,
+		QuestionSeconds:  g.QuestionSeconds,
+		CountdownSeconds: g.CountdownSeconds,
+		RevealSeconds:    g.RevealSeconds,
+		MaxPlayers:       g.MaxPlayers,
+		ScoringMode:      g.ScoringMode,
+		Categories:       g.Categories,
+		Private:          g.Private,
+		HasPassword:      g.HasPassword(),
+		State:            g.State,
 	}
 }
 
 func (g RepositoryGame) ToHash() map[string]string {
 	return map[string]string{
-		"id":             g.ID.String(),
-		"name":           g.Name,
-		"player_count":   strconv.Itoa(g.PlayerCount),
-		"question_count": strconv.Itoa(g.QuestionCount),
-		"state":          string(g.State),
+		"id":                g.ID.String(),
+		"name":              g.Name,
+		"player_count":      strconv.Itoa(g.PlayerCount),
+		"question_count":    strconv.Itoa(g.QuestionCount),
+		"mode":              string(g.Mode),
+		"question_seconds":  strconv.Itoa(g.QuestionSeconds),
+		"countdown_seconds": strconv.Itoa(g.CountdownSeconds),
+		"reveal_seconds":    strconv.Itoa(g.RevealSeconds),
+		"max_players":       strconv.Itoa(g.MaxPlayers),
+		"scoring_mode":      string(g.ScoringMode),
+		"categories":        strings.Join(g.Categories, ","),
+		"private":           strconv.FormatBool(g.Private),
+		"has_password":      strconv.FormatBool(g.HasPassword),
+		"state":             string(g.State),
 	}
 }
 
 func newGame(name string, qCount int) *Game {
 	return &Game{
-		ID:            uuid.New(),
-		Name:          name,
-		PlayersReady:  make(map[string]bool),
-		QuestionCount: qCount,
-		State:         GameStateWaiting,
-		Scores:        make(map[string]int),
-		gameEnded:     make(chan bool, 1),
+		ID:             uuid.New(),
+		Name:           name,
+		PlayersReady:   make(map[string]bool),
+		QuestionCount:  qCount,
+		ScoringMode:    ScoringModeFirstCorrect,
+		StartPolicy:    StartPolicy{Mode: StartPolicyManual},
+		State:          GameStateWaiting,
+		Scores:         make(map[string]int),
+		scoring:        firstCorrectScoring{},
+		streaks:        make(map[string]int),
+		correctPlayers: make(map[string]bool),
+		answerCounts:   make(map[string]int),
+		playerTeams:    make(map[string]string),
+		teamScores:     make(map[string]int),
+		teamsScored:    make(map[string]bool),
+		livesLost:      make(map[string]int),
+		questionsShown: make(map[string]int),
+		Mode:           GameModeClassic,
+		spectators:     make(map[string]bool),
+		gameEnded:      make(chan bool, 1),
 	}
 }
 
-func NewGame(name string, qCount int) (*Game, error) {
+// NewGame creates a game with qCount questions drawn from the bank.
+func NewGame(name string, qCount int, bank QuestionBank, filter QuestionFilter) (*Game, error) {
 	game := newGame(name, qCount)
 
-	filePath := os.Getenv("QUESTIONS_FILE_PATH")
-
-	log.Println("filepath: ", filePath)
-
-	questions, err := LoadQuestions(filePath)
+	questions, version, err := bank.Draw(qCount, filter)
 	if err != nil {
-		return nil, fmt.Errorf("error loading questions: %s", err)
+		return nil, fmt.Errorf("error drawing questions: %w", err)
 	}
-
-	shuffled := ShuffleQuestions(questions, qCount)
-	game.questions = shuffled
+	game.questions = questions
+	game.BankVersion = version
 
 	return game, nil
 }
 
-func (g *Game) AddPlayer(player string) {
+// AddPlayer seats a player in the game. Adding a player that already has a
+// seat, such as one rejoining a recovered game, keeps their score and
+// readiness. The first player seated, the game's creator, becomes the host.
+// ErrGameFull is returned if the game has no free seat.
+func (g *Game) AddPlayer(player string) error {
 	g.mu.Lock()
+	if _, ok := g.PlayersReady[player]; ok {
+		g.mu.Unlock()
+		return nil
+	}
+	if g.MaxPlayers > 0 && g.PlayerCount >= g.MaxPlayers {
+		g.mu.Unlock()
+		return ErrGameFull
+	}
+	delete(g.spectators, player)
 	g.PlayersReady[player] = false
 	g.Scores[player] = 0
 	g.PlayerCount++
+	g.joinOrder = append(g.joinOrder, player)
+	if g.Host == "" {
+		g.Host = player
+	}
 	g.mu.Unlock()
+	return nil
+}
+
+// Full reports whether every seat in the game is taken.
+func (g *Game) Full() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.MaxPlayers > 0 && g.PlayerCount >= g.MaxPlayers
 }
 
+// RemovePlayer removes the player's seat. If the player was the host, the host
+// passes to the player that has been seated the longest.
 func (g *Game) RemovePlayer(player string) {
 	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.PlayersReady[player]; !ok {
+		return
+	}
 	delete(g.PlayersReady, player)
 	delete(g.Scores, player)
+	delete(g.playerTeams, player)
 	g.PlayerCount--
-	g.mu.Unlock()
+	g.joinOrder = slices.DeleteFunc(g.joinOrder, func(p string) bool { return p == player })
+
+	if g.Host == player {
+		g.Host = ""
+		if len(g.joinOrder) > 0 {
+			g.Host = g.joinOrder[0]
+		}
+	}
 }
 
-func (g *Game) PlayerReady(player string) {
+func (g *Game) IsHost(player string) bool {
 	g.mu.Lock()
-	g.PlayersReady[player] = true
-	g.mu.Unlock()
+	defer g.mu.Unlock()
+	return g.Host == player
+}
+
+// TransferHost makes another seated player the host.
+func (g *Game) TransferHost(player string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.PlayersReady[player]; !ok {
+		return fmt.Errorf("%w: %s", ErrPlayerNotInGame, player)
+	}
+	g.Host = player
+	return nil
+}
+
+// Reconfigure changes the settings of a game that has not started, drawing a
+// new set of questions from the bank. An empty name or zero setting keeps the
+// current value.
+func (g *Game) Reconfigure(name string, u GameSettings, bank QuestionBank) error {
+	if g.State != GameStateWaiting {
+		return ErrGameStarted
+	}
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	settings := g.Settings().update(u)
+	if settings.MaxPlayers > 0 && settings.MaxPlayers < g.PlayerCount {
+		return fmt.Errorf("max_players can not be less than the %d players in the game", g.PlayerCount)
+	}
+
+	questions, version, err := bank.Draw(settings.QuestionCount, settings.QuestionFilter)
+	if err != nil {
+		return fmt.Errorf("error drawing questions: %w", err)
+	}
+	if err := g.ApplySettings(settings); err != nil {
+		return err
+	}
+
+	if name != "" {
+		g.Name = name
+	}
+	g.QuestionCount = settings.QuestionCount
+	g.questions = questions
+	g.BankVersion = version
+	return nil
 }
 
-func (g *Game) AddQuestions(questions []Question) {
-	g.questions = append(g.questions, questions...)
+// PlayerReady marks a seated player as ready. Players without a seat are
+// ignored.
+func (g *Game) PlayerReady(player string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.PlayersReady[player]; ok {
+		g.PlayersReady[player] = true
+	}
 }
 
 func (g *Game) PlayerScores() []PlayerScore {
@@ -177,35 +365,163 @@ func (g *Game) CurrentQuestion() Question {
 	return g.questions[g.currentQuestionIndex]
 }
 
+// GoToNextQuestion moves on to the next question, the game ends after its
+// last question or once a survival game has been decided.
 func (g *Game) GoToNextQuestion() {
-	if g.IsLastQuestion() {
+	g.resetQuestionScoring()
+	g.mu.Lock()
+	decided := g.survivalDecided()
+	g.mu.Unlock()
+	if decided || g.IsLastQuestion() {
 		g.gameEnded <- true
 		return
 	}
+	g.mu.Lock()
 	g.currentQuestionIndex++
+	g.mu.Unlock()
+}
+
+// resetQuestionScoring breaks the streak of every player that did not answer
+// the current question correctly and clears who answered it.
+func (g *Game) resetQuestionScoring() {
+	g.mu.Lock()
+	for player := range g.streaks {
+		if !g.correctPlayers[player] {
+			g.streaks[player] = 0
+		}
+	}
+	g.scoreTeamVotes()
+	clear(g.correctPlayers)
+	clear(g.answerCounts)
+	clear(g.teamsScored)
+	g.mu.Unlock()
 }
 
 func (g *Game) IsLastQuestion() bool {
 	return g.currentQuestionIndex >= (len(g.questions) - 1)
 }
 
-func (g *Game) StartGame() {
-	g.State = GameStateCountdown
+// HasPlayer reports whether the player has a seat in the game.
+func (g *Game) HasPlayer(player string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.PlayersReady[player]
+	return ok
+}
+
+// InProgress reports whether the game has started and not yet finished.
+func (g *Game) InProgress() bool {
+	return g.State != GameStateWaiting && !g.State.Finished()
+}
+
+// SetDeadline records when the current countdown or question is due to end.
+func (g *Game) SetDeadline(t time.Time) {
+	g.mu.Lock()
+	g.deadline = t
+	g.mu.Unlock()
+}
+
+func (g *Game) Deadline() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.deadline
+}
+
+// StartGame moves a waiting game on to its first countdown. Games that have
+// already started can not be started again.
+func (g *Game) StartGame() error {
+	return g.TransitionTo(GameStateCountdown)
 }
 
 func (g *Game) ValidateAnswer(index int) bool {
 	return index == g.questions[g.currentQuestionIndex].CorrectIndex
 }
 
-func (g *Game) IncrementPlayerScore(player string) {
-	g.Scores[player] += 1
+// SetScoringMode changes how answers are scored. An empty mode falls back to
+// first-correct-wins.
+func (g *Game) SetScoringMode(mode ScoringMode) error {
+	strategy, err := NewScoringStrategy(mode)
+	if err != nil {
+		return err
+	}
+	if mode == "" {
+		mode = ScoringModeFirstCorrect
+	}
+
+	g.mu.Lock()
+	g.ScoringMode = mode
+	g.scoring = strategy
+	g.mu.Unlock()
+	return nil
 }
 
-func (g *Game) GameEndedChan() chan bool {
-	return g.gameEnded
+// ScoreAnswer awards the points for a player's answer to the current question
+// according to the game's scoring mode. The question should be closed when
+// the returned outcome says so, either because the mode ends questions on the
+// first correct answer or because every player has answered correctly. Team
+// games played with such a mode end the question once every team has scored
+// it instead.
+func (g *Game) ScoreAnswer(player string, correct bool, answeredAt time.Time, questionDuration time.Duration) AnswerOutcome {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.correctPlayers[player] {
+		return AnswerOutcome{}
+	}
+	if g.correctPlayers == nil {
+		g.correctPlayers = make(map[string]bool)
+		g.streaks = make(map[string]int)
+	}
+
+	if correct {
+		g.correctPlayers[player] = true
+		g.streaks[player]++
+	} else {
+		g.streaks[player] = 0
+	}
+
+	scoring := g.scoring
+	if scoring == nil {
+		scoring = firstCorrectScoring{}
+	}
+	points := scoring.Points(ScoredAnswer{
+		Player:           player,
+		Correct:          correct,
+		Elapsed:          answeredAt.Sub(g.questionDisplayedAt),
+		QuestionDuration: questionDuration,
+		Streak:           g.streaks[player],
+	})
+	g.Scores[player] += points
+	g.scoreTeamAnswer(player, correct, points)
+
+	outcome := AnswerOutcome{
+		Accepted: true,
+		Points:   points,
+	}
+	if correct {
+		closes := scoring.ClosesOnCorrect()
+		if closes && len(g.Teams) > 0 {
+			// each team plays the question out, a correct answer from one
+			// team doesn't close it on the others
+			closes = g.teamsClosed()
+		}
+		outcome.CloseQuestion = closes || len(g.correctPlayers) >= g.PlayerCount
+	}
+	return outcome
+}
+
+// QuestionDisplayed records when the current question was shown to players so
+// answer times can be measured from it, and counts it as shown to every
+// seated player.
+func (g *Game) QuestionDisplayed(t time.Time) {
+	g.mu.Lock()
+	g.questionDisplayedAt = t
+	for player := range g.PlayersReady {
+		g.questionsShown[player]++
+	}
+	g.mu.Unlock()
 }
 
-func (g *Game) EndGame() {
-	clear(g.PlayersReady)
-	g.PlayerCount = 0
+func (g *Game) GameEndedChan() chan bool {
+	return g.gameEnded
 }
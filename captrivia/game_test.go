@@ -14,8 +14,10 @@ const (
 	questionCount = 5
 )
 
+var testBank, _ = captrivia.LoadQuestionBank("../questions.json")
+
 func CreateTestGame() captrivia.Game {
-	g, err := captrivia.NewGame(gameName, questionCount)
+	g, err := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
 	if err != nil {
 		log.Println("couldn't load questions for test game")
 		return captrivia.Game{}
@@ -24,7 +26,7 @@ func CreateTestGame() captrivia.Game {
 }
 
 func TestNewGame(t *testing.T) {
-	g, _ := captrivia.NewGame(gameName, questionCount)
+	g, _ := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
 
 	assert.Equal(t, gameName, g.Name)
 	assert.Equal(t, questionCount, g.QuestionCount)
@@ -93,6 +95,48 @@ func TestAddPlayer(t *testing.T) {
 	assert.Equal(t, 2, g.PlayerCount)
 }
 
+func TestPlayerReady(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player")
+
+	g.PlayerReady("player")
+	assert.Equal(t, 1, g.ReadyCount())
+
+	// readying up doesn't give a seat to a player without one
+	g.PlayerReady("stranger")
+	assert.False(t, g.HasPlayer("stranger"))
+	assert.Equal(t, 1, g.ReadyCount())
+}
+
+func TestAddPlayerFull(t *testing.T) {
+	g := CreateTestGame()
+	g.MaxPlayers = 2
+
+	assert.NoError(t, g.AddPlayer("player 1"))
+	assert.NoError(t, g.AddPlayer("player 2"))
+	assert.True(t, g.Full())
+	assert.ErrorIs(t, g.AddPlayer("player 3"), captrivia.ErrGameFull)
+	assert.Equal(t, 2, g.PlayerCount)
+
+	// seated players can always rejoin
+	assert.NoError(t, g.AddPlayer("player 1"))
+
+	// a spectator takes the seat a player leaves
+	g.AddSpectator("player 3")
+	assert.True(t, g.IsSpectator("player 3"))
+	assert.Equal(t, 1, g.SpectatorCount())
+	g.RemovePlayer("player 2")
+	assert.NoError(t, g.AddPlayer("player 3"))
+	assert.False(t, g.IsSpectator("player 3"))
+	assert.Zero(t, g.SpectatorCount())
+
+	// seated players are never spectators
+	g.AddSpectator("player 1")
+	assert.False(t, g.IsSpectator("player 1"))
+
+	assert.Error(t, g.Reconfigure(g.Name, captrivia.GameSettings{MaxPlayers: 1}, testBank))
+}
+
 func TestRemovePlayer(t *testing.T) {
 	g := CreateTestGame()
 
@@ -105,8 +149,59 @@ func TestRemovePlayer(t *testing.T) {
 	assert.Empty(t, 0, g.PlayerScores)
 }
 
+func TestHost(t *testing.T) {
+	g := CreateTestGame()
+
+	g.AddPlayer("creator")
+	g.AddPlayer("second")
+	g.AddPlayer("third")
+	assert.Equal(t, "creator", g.Host)
+	assert.True(t, g.IsHost("creator"))
+	assert.False(t, g.IsHost("second"))
+
+	// removing a player that is not the host keeps the host
+	g.RemovePlayer("third")
+	assert.Equal(t, "creator", g.Host)
+
+	g.AddPlayer("third")
+	g.RemovePlayer("creator")
+	assert.Equal(t, "second", g.Host)
+
+	assert.ErrorIs(t, g.TransferHost("creator"), captrivia.ErrPlayerNotInGame)
+	assert.NoError(t, g.TransferHost("third"))
+	assert.Equal(t, "third", g.Host)
+
+	g.RemovePlayer("third")
+	g.RemovePlayer("second")
+	assert.Equal(t, "", g.Host)
+}
+
+func TestReconfigure(t *testing.T) {
+	g := CreateTestGame()
+
+	err := g.Reconfigure("renamed", captrivia.GameSettings{QuestionCount: 2, QuestionSeconds: 20, ScoringMode: captrivia.ScoringModeAllCorrect}, testBank)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", g.Name)
+	assert.Equal(t, 2, g.QuestionCount)
+	assert.Equal(t, 20, g.QuestionSeconds)
+	assert.Equal(t, captrivia.ScoringModeAllCorrect, g.ScoringMode)
+
+	// zero values keep the current settings
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{}, testBank))
+	assert.Equal(t, "renamed", g.Name)
+	assert.Equal(t, 2, g.QuestionCount)
+	assert.Equal(t, 20, g.QuestionSeconds)
+
+	assert.Error(t, g.Reconfigure("", captrivia.GameSettings{ScoringMode: "unknown"}, testBank))
+	assert.Error(t, g.Reconfigure("", captrivia.GameSettings{QuestionSeconds: 1000}, testBank))
+	assert.Equal(t, 20, g.QuestionSeconds)
+
+	assert.NoError(t, g.StartGame())
+	assert.ErrorIs(t, g.Reconfigure("too late", captrivia.GameSettings{}, testBank), captrivia.ErrGameStarted)
+}
+
 func TestIsLastQuestion(t *testing.T) {
-	g, err := captrivia.NewGame("test last question", 3)
+	g, err := captrivia.NewGame("test last question", 3, testBank, captrivia.QuestionFilter{})
 	if err != nil {
 		t.Error(err)
 	}
@@ -121,7 +216,7 @@ func TestIsLastQuestion(t *testing.T) {
 }
 
 func TestGoToNextQuestion(t *testing.T) {
-	g, err := captrivia.NewGame("test game", 5)
+	g, err := captrivia.NewGame("test game", 5, testBank, captrivia.QuestionFilter{})
 	if err != nil {
 		t.Error(err)
 	}
@@ -138,7 +233,7 @@ func TestGoToNextQuestion(t *testing.T) {
 	assert.Equal(t, 3, g.CurrentIndex())
 }
 func TestGameEnd(t *testing.T) {
-	g, err := captrivia.NewGame("test end of game", 1)
+	g, err := captrivia.NewGame("test end of game", 1, testBank, captrivia.QuestionFilter{})
 	if err != nil {
 		t.Error(err)
 	}
@@ -0,0 +1,34 @@
+package captrivia
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// InviteCodeLength is the number of letters in a private game's invite code.
+const InviteCodeLength = 6
+
+// letters that can't be mistaken for one another when read aloud or copied
+// by hand, I and O are left out
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// NewInviteCode returns a random invite code for a private game.
+func NewInviteCode() string {
+	var b strings.Builder
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for range InviteCodeLength {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b.WriteByte(inviteCodeAlphabet[n.Int64()])
+	}
+	return b.String()
+}
+
+// NormalizeInviteCode puts an invite code typed by a player in the form it
+// is stored in.
+func NormalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,19 @@
+package captrivia_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInviteCode(t *testing.T) {
+	code := captrivia.NewInviteCode()
+
+	assert.Len(t, code, captrivia.InviteCodeLength)
+	assert.Equal(t, strings.ToUpper(code), code)
+	assert.NotContains(t, code, "I")
+	assert.NotContains(t, code, "O")
+	assert.Equal(t, code, captrivia.NormalizeInviteCode(" "+strings.ToLower(code)+"\n"))
+}
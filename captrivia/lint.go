@@ -0,0 +1,194 @@
+package captrivia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// question texts sharing at least this fraction of their words are
+	// reported as near-duplicates
+	nearDuplicateSimilarity = 0.8
+	// answer-position bias is only reported once there are enough questions
+	// with the same number of options for the distribution to mean anything
+	minBiasSample = 8
+)
+
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is a problem found in a set of questions. QuestionID is empty for
+// issues that concern the whole set.
+type LintIssue struct {
+	Severity   LintSeverity `json:"severity"`
+	QuestionID string       `json:"question_id,omitempty"`
+	Message    string       `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	if i.QuestionID == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: question %s: %s", i.Severity, i.QuestionID, i.Message)
+}
+
+// LintQuestionsFile strictly decodes a questions file, rejecting unknown
+// fields, and lints its questions. An error is returned when the file can not
+// be read or decoded.
+func LintQuestionsFile(filename string) ([]LintIssue, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read questions file: %w", err)
+	}
+
+	questions, err := decodeQuestionsStrict(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return LintQuestions(questions), nil
+}
+
+func decodeQuestionsStrict(data []byte) ([]Question, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var questions []Question
+	if err := decoder.Decode(&questions); err != nil {
+		return nil, fmt.Errorf("failed to decode questions: %w", err)
+	}
+	return questions, nil
+}
+
+// LintQuestions reports schema errors, duplicate IDs, duplicate options,
+// near-duplicate question texts and bias in the position of correct answers.
+func LintQuestions(questions []Question) []LintIssue {
+	var issues []LintIssue
+
+	ids := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		if err := q.Validate(); err != nil {
+			issues = append(issues, LintIssue{LintError, q.ID, strings.TrimPrefix(err.Error(), "question "+q.ID+": ")})
+		}
+		if q.ID != "" && ids[q.ID] {
+			issues = append(issues, LintIssue{LintError, q.ID, "duplicate id"})
+		}
+		ids[q.ID] = true
+
+		options := make(map[string]bool, len(q.Options))
+		for _, option := range q.Options {
+			normalized := normalizeText(option)
+			if options[normalized] {
+				issues = append(issues, LintIssue{LintError, q.ID, fmt.Sprintf("duplicate option %q", option)})
+			}
+			options[normalized] = true
+		}
+	}
+
+	issues = append(issues, lintNearDuplicates(questions)...)
+	issues = append(issues, lintAnswerPositions(questions)...)
+
+	return issues
+}
+
+func lintNearDuplicates(questions []Question) []LintIssue {
+	var issues []LintIssue
+
+	words := make([]map[string]bool, len(questions))
+	for i, q := range questions {
+		words[i] = make(map[string]bool)
+		for _, w := range strings.Fields(normalizeText(q.QuestionText)) {
+			words[i][w] = true
+		}
+	}
+
+	for i := range questions {
+		for j := i + 1; j < len(questions); j++ {
+			if similarity(words[i], words[j]) >= nearDuplicateSimilarity {
+				issues = append(issues, LintIssue{LintWarning, questions[j].ID, fmt.Sprintf("question text is a near-duplicate of question %s", questions[i].ID)})
+			}
+		}
+	}
+
+	return issues
+}
+
+// lintAnswerPositions warns when the correct answer is found in one position
+// far more or less often than chance among questions with the same number of
+// options.
+func lintAnswerPositions(questions []Question) []LintIssue {
+	var issues []LintIssue
+
+	positions := make(map[int][]int) // option count -> correct answers per position
+	totals := make(map[int]int)
+	for _, q := range questions {
+		n := len(q.Options)
+		if n < 2 || q.CorrectIndex < 0 || q.CorrectIndex >= n {
+			continue
+		}
+		if positions[n] == nil {
+			positions[n] = make([]int, n)
+		}
+		positions[n][q.CorrectIndex]++
+		totals[n]++
+	}
+
+	optionCounts := make([]int, 0, len(positions))
+	for n := range positions {
+		optionCounts = append(optionCounts, n)
+	}
+	sort.Ints(optionCounts)
+
+	for _, n := range optionCounts {
+		counts, total := positions[n], totals[n]
+		if total < minBiasSample {
+			continue
+		}
+		expected := float64(total) / float64(n)
+		for position, count := range counts {
+			if float64(count) > 2*expected || float64(count) < expected/2 {
+				issues = append(issues, LintIssue{
+					Severity: LintWarning,
+					Message: fmt.Sprintf("correct answer is option %d in %d of %d questions with %d options, expected about %.0f",
+						position, count, total, n, expected),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// normalizeText lower cases the text and drops punctuation so that trivially
+// different texts compare equal.
+func normalizeText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// similarity is the Jaccard index of two sets of words.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,61 @@
+package captrivia_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func lintQuestion(id string, text string, correct int) captrivia.Question {
+	return captrivia.Question{ID: id, QuestionText: text, Options: []string{"a", "b", "c", "d"}, CorrectIndex: correct}
+}
+
+func TestLintQuestions(t *testing.T) {
+	questions := []captrivia.Question{
+		lintQuestion("1", "What does dilution mean?", 0),
+		lintQuestion("1", "What is an option pool?", 1),
+		lintQuestion("2", "What does 'dilution' mean!", 2),
+		{ID: "3", QuestionText: "Who uses a cap table?", Options: []string{"Startups", "startups"}},
+		{ID: "4", QuestionText: "What is a SAFE?", Options: []string{"a"}},
+	}
+
+	issues := captrivia.LintQuestions(questions)
+
+	assert.Contains(t, issues, captrivia.LintIssue{Severity: captrivia.LintError, QuestionID: "1", Message: "duplicate id"})
+	assert.Contains(t, issues, captrivia.LintIssue{Severity: captrivia.LintWarning, QuestionID: "2", Message: "question text is a near-duplicate of question 1"})
+	assert.Contains(t, issues, captrivia.LintIssue{Severity: captrivia.LintError, QuestionID: "3", Message: `duplicate option "startups"`})
+	assert.Contains(t, issues, captrivia.LintIssue{Severity: captrivia.LintError, QuestionID: "4", Message: "at least 2 options are required"})
+	assert.Len(t, issues, 4)
+}
+
+func TestLintAnswerPositionBias(t *testing.T) {
+	var questions []captrivia.Question
+	for i := 0; i < 8; i++ {
+		questions = append(questions, lintQuestion(fmt.Sprint(i), fmt.Sprintf("question number %d", i), i%2))
+	}
+
+	issues := captrivia.LintQuestions(questions)
+
+	// options 2 and 3 are never correct
+	assert.Len(t, issues, 2)
+	for _, issue := range issues {
+		assert.Equal(t, captrivia.LintWarning, issue.Severity)
+	}
+
+	for i := range questions {
+		questions[i].CorrectIndex = i % 4
+	}
+	assert.Empty(t, captrivia.LintQuestions(questions))
+}
+
+func TestLintQuestionsFileUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.json")
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q","options":["a","b"],"correct_index":1}]`), 0o644)
+
+	_, err := captrivia.LintQuestionsFile(path)
+	assert.NotNil(t, err)
+}
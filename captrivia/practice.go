@@ -0,0 +1,79 @@
+package captrivia
+
+// PracticeSummary is shown to the player of a practice game once it is over.
+type PracticeSummary struct {
+	Questions int     `json:"questions"` // questions the player was shown
+	Answered  int     `json:"answered"`
+	Correct   int     `json:"correct"`
+	Skipped   int     `json:"skipped"`  // questions the player moved on from without answering
+	Accuracy  float64 `json:"accuracy"` // fraction of the answered questions the player got right
+	Score     int     `json:"score"`
+}
+
+// Practice returns the settings of a practice game played with the settings
+// s. Practice games are played alone without a lobby, and without time
+// limits as the player moves on to each question when they are ready. A
+// practice game without a question_count draws DefaultQuestionCount.
+func (s GameSettings) Practice() GameSettings {
+	s.Mode = GameModePractice
+	if s.QuestionCount == 0 {
+		s.QuestionCount = DefaultQuestionCount
+	}
+	s.Lives = 0
+	s.QuestionSeconds = 0
+	s.CountdownSeconds = 0
+	s.RevealSeconds = 0
+	s.MaxPlayers = 1
+	s.AnswersPerQuestion = 0
+	s.StartPolicy = StartPolicy{}
+	s.Private = nil
+	s.Password = ""
+	s.ClearPassword = false
+	s.Teams = nil
+	s.TeamScoring = ""
+	return s
+}
+
+// IsPractice reports whether the game is a practice game.
+func (g *Game) IsPractice() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Mode == GameModePractice
+}
+
+// UpdatesLeaderboard reports whether the game's answers count towards its
+// players' stats. Practice games only count when they are ranked.
+func (g *Game) UpdatesLeaderboard() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Mode != GameModePractice || g.Ranked
+}
+
+// PracticeSummary returns how the player of a practice game did on the
+// questions they have been shown, built from the answer log.
+func (g *Game) PracticeSummary(player string) PracticeSummary {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	summary := PracticeSummary{
+		Questions: min(g.currentQuestionIndex+1, len(g.questions)),
+		Score:     g.Scores[player],
+	}
+	if g.State == GameStateWaiting {
+		summary.Questions = 0
+	}
+	for _, a := range g.answers {
+		if a.Player != player || !a.Accepted {
+			continue
+		}
+		summary.Answered++
+		if a.Correct {
+			summary.Correct++
+		}
+	}
+	summary.Skipped = summary.Questions - summary.Answered
+	if summary.Answered > 0 {
+		summary.Accuracy = float64(summary.Correct) / float64(summary.Answered)
+	}
+	return summary
+}
@@ -0,0 +1,63 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPracticeSettings(t *testing.T) {
+	settings := captrivia.GameSettings{Mode: captrivia.GameModePractice}
+	assert.Error(t, settings.Validate())
+
+	private := true
+	settings = captrivia.GameSettings{
+		QuestionCount:   5,
+		QuestionSeconds: 20,
+		MaxPlayers:      8,
+		Private:         &private,
+		Password:        "secret",
+		Teams:           []string{"red", "blue"},
+		ScoringMode:     captrivia.ScoringModeStreak,
+	}.Practice()
+	assert.Equal(t, captrivia.GameSettings{
+		QuestionCount: 5,
+		Mode:          captrivia.GameModePractice,
+		MaxPlayers:    1,
+		ScoringMode:   captrivia.ScoringModeStreak,
+	}, settings)
+
+	assert.Equal(t, captrivia.DefaultQuestionCount, captrivia.GameSettings{}.Practice().QuestionCount)
+}
+
+func TestPracticeSummary(t *testing.T) {
+	g := CreateTestGame()
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{}.Practice()))
+	g.AddPlayer("solo")
+	assert.False(t, g.UpdatesLeaderboard())
+	g.Ranked = true
+	assert.True(t, g.UpdatesLeaderboard())
+
+	now := time.Now()
+	g.State = captrivia.GameStateQuestion
+	q := g.CurrentQuestion()
+	g.AnswerQuestion("solo", q.ID, q.CorrectIndex, now, 0)
+	g.GoToNextQuestion()
+
+	q = g.CurrentQuestion()
+	g.AnswerQuestion("solo", q.ID, (q.CorrectIndex+1)%len(q.Options), now, 0)
+	g.GoToNextQuestion()
+
+	// the third question is shown but not answered
+
+	assert.Equal(t, captrivia.PracticeSummary{
+		Questions: 3,
+		Answered:  2,
+		Correct:   1,
+		Skipped:   1,
+		Accuracy:  0.5,
+		Score:     1,
+	}, g.PracticeSummary("solo"))
+}
@@ -2,27 +2,112 @@ package captrivia
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 )
 
+var (
+	ErrQuestionExists   = errors.New("question already exists")
+	ErrQuestionNotFound = errors.New("question not found")
+)
+
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
 // Question is a read-only representation of a question, meaning it can be
 // safely copied around despite the presence of a slice.
 type Question struct {
-	ID           string   `json:"id"`
-	QuestionText string   `json:"questionText"`
-	Options      []string `json:"options"`
-	CorrectIndex int      `json:"correctIndex"` //TODO: remove this from frontend
+	ID           string     `json:"id"`
+	QuestionText string     `json:"questionText"`
+	Options      []string   `json:"options"`
+	CorrectIndex int        `json:"correctIndex"` //TODO: remove this from frontend
+	Category     string     `json:"category,omitempty"`
+	Difficulty   Difficulty `json:"difficulty,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Explanation  string     `json:"explanation,omitempty"` // shown once the question has been answered
+	Retired      bool       `json:"retired,omitempty"`     // retired questions are kept but no longer drawn for games
+}
+
+// QuestionService stores the questions managed through the admin API. Every
+// change to the stored questions moves them on to a new version.
+type QuestionService interface {
+	GetQuestions() ([]Question, error)
+	GetQuestion(id string) (Question, error)
+	CreateQuestion(q Question) error
+	UpdateQuestion(q Question) error
+	RetireQuestion(id string) error
+	ImportQuestions(questions []Question) error
+
+	// GetQuestionSet returns every question along with their version.
+	GetQuestionSet() (string, []Question, error)
+	GetQuestionsVersion() (string, error)
+	// MergeQuestions merges the questions loaded from the questions file into
+	// the stored questions (see MergeQuestionsFile), recording the source (a
+	// hash of the questions file) they were loaded from.
+	MergeQuestions(source string, questions []Question) error
+	GetQuestionsSource() (string, error)
 }
 
 func NewQuestion(id string, question string, options []string, cIndex int) *Question {
 	return &Question{
-		id,
-		question,
-		options,
-		cIndex,
+		ID:           id,
+		QuestionText: question,
+		Options:      options,
+		CorrectIndex: cIndex,
+	}
+}
+
+func (d Difficulty) Validate() error {
+	switch d {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return nil
 	}
+	return fmt.Errorf("unknown difficulty %q", d)
+}
+
+// Validate checks the question can be used in a game.
+func (q Question) Validate() error {
+	if q.ID == "" {
+		return fmt.Errorf("question id is required")
+	}
+	if q.QuestionText == "" {
+		return fmt.Errorf("question %s: questionText is required", q.ID)
+	}
+	if len(q.Options) < 2 {
+		return fmt.Errorf("question %s: at least 2 options are required", q.ID)
+	}
+	if q.CorrectIndex < 0 || q.CorrectIndex >= len(q.Options) {
+		return fmt.Errorf("question %s: correctIndex %d is out of range", q.ID, q.CorrectIndex)
+	}
+	if q.Difficulty != "" {
+		if err := q.Difficulty.Validate(); err != nil {
+			return fmt.Errorf("question %s: %w", q.ID, err)
+		}
+	}
+	return nil
+}
+
+// ValidateQuestions validates every question and checks that no two
+// questions share an ID.
+func ValidateQuestions(questions []Question) error {
+	ids := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		if err := q.Validate(); err != nil {
+			return err
+		}
+		if ids[q.ID] {
+			return fmt.Errorf("question %s: duplicate id", q.ID)
+		}
+		ids[q.ID] = true
+	}
+	return nil
 }
 
 // LoadQuestions reads a JSON file containing a list of questions to be used
@@ -49,14 +134,24 @@ func LoadQuestions(filename string) ([]Question, error) {
 // length of the questions slice, as the implementation is not efficient as n
 // approaches len(questions).
 func ShuffleQuestions(questions []Question, n int) []Question {
+	return shuffleQuestions(questions, n, nil)
+}
+
+// shuffleQuestions is ShuffleQuestions picking the questions with rng, or with
+// the global source when rng is nil.
+func shuffleQuestions(questions []Question, n int, rng *rand.Rand) []Question {
 	q := make([]Question, n)
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
 
 	// This is no fisher-yates shuffle, but in theory the set of all questions
 	// available for use in games should be massive.
 	usedQuestions := map[int]struct{}{}
 	for i := range q {
 		for {
-			index := rand.Intn(len(questions))
+			index := intn(len(questions))
 			if _, ok := usedQuestions[index]; !ok {
 				usedQuestions[index] = struct{}{}
 				q[i] = questions[index]
@@ -0,0 +1,87 @@
+package captrivia
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvListSeparator separates the options and tags held in a single CSV column
+const csvListSeparator = "|"
+
+var requiredCSVColumns = []string{"id", "questionText", "options", "correctIndex"}
+
+// ParseQuestionsCSV reads questions from CSV with a header row. The id,
+// questionText, options and correctIndex columns are required and category,
+// difficulty, tags and explanation are optional. Options and tags are
+// separated by a pipe.
+func ParseQuestionsCSV(r io.Reader) ([]Question, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv is missing the %s column", name)
+		}
+	}
+
+	var questions []Question
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading csv: %w", err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		correctIndex, err := strconv.Atoi(field("correctIndex"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: correctIndex must be an integer", line)
+		}
+
+		q := Question{
+			ID:           field("id"),
+			QuestionText: field("questionText"),
+			Options:      splitCSVList(field("options")),
+			CorrectIndex: correctIndex,
+			Category:     field("category"),
+			Difficulty:   Difficulty(field("difficulty")),
+			Tags:         splitCSVList(field("tags")),
+			Explanation:  field("explanation"),
+		}
+		questions = append(questions, q)
+	}
+
+	return questions, nil
+}
+
+func splitCSVList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	items := strings.Split(s, csvListSeparator)
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
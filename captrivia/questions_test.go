@@ -0,0 +1,62 @@
+package captrivia_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuestionValidate(t *testing.T) {
+	valid := captrivia.Question{ID: "1", QuestionText: "q", Options: []string{"a", "b"}, CorrectIndex: 1}
+	assert.Nil(t, valid.Validate())
+
+	tests := map[string]func(q *captrivia.Question){
+		"missing id":         func(q *captrivia.Question) { q.ID = "" },
+		"missing text":       func(q *captrivia.Question) { q.QuestionText = "" },
+		"one option":         func(q *captrivia.Question) { q.Options = []string{"a"} },
+		"correct index high": func(q *captrivia.Question) { q.CorrectIndex = 2 },
+		"correct index low":  func(q *captrivia.Question) { q.CorrectIndex = -1 },
+		"unknown difficulty": func(q *captrivia.Question) { q.Difficulty = "expert" },
+	}
+	for name, modify := range tests {
+		q := valid
+		modify(&q)
+		assert.NotNil(t, q.Validate(), name)
+	}
+}
+
+func TestValidateQuestionsDuplicateID(t *testing.T) {
+	q := captrivia.Question{ID: "1", QuestionText: "q", Options: []string{"a", "b"}}
+	assert.Nil(t, captrivia.ValidateQuestions([]captrivia.Question{q}))
+	assert.NotNil(t, captrivia.ValidateQuestions([]captrivia.Question{q, q}))
+}
+
+func TestParseQuestionsCSV(t *testing.T) {
+	csv := `id,questionText,options,correctIndex,category,difficulty,tags,explanation
+1,"What is dilution?","debt | ownership reduction",1,equity,easy,dilution|basics,"New shares reduce ownership."
+2,What is a SAFE?,a|b|c,0,,,,
+`
+	questions, err := captrivia.ParseQuestionsCSV(strings.NewReader(csv))
+	assert.Nil(t, err)
+	assert.Len(t, questions, 2)
+
+	assert.Equal(t, captrivia.Question{
+		ID:           "1",
+		QuestionText: "What is dilution?",
+		Options:      []string{"debt", "ownership reduction"},
+		CorrectIndex: 1,
+		Category:     "equity",
+		Difficulty:   captrivia.DifficultyEasy,
+		Tags:         []string{"dilution", "basics"},
+		Explanation:  "New shares reduce ownership.",
+	}, questions[0])
+	assert.Nil(t, questions[1].Tags)
+
+	_, err = captrivia.ParseQuestionsCSV(strings.NewReader("id,questionText\n1,q\n"))
+	assert.NotNil(t, err)
+
+	_, err = captrivia.ParseQuestionsCSV(strings.NewReader("id,questionText,options,correctIndex\n1,q,a|b,first\n"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,139 @@
+package captrivia
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// QuestionReloader loads a questions file into a QuestionService, merging it
+// into the stored questions whenever the file's contents change. A file that
+// fails validation is rejected and the stored questions are left untouched.
+type QuestionReloader struct {
+	path    string
+	service QuestionService
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+func NewQuestionReloader(path string, service QuestionService) *QuestionReloader {
+	return &QuestionReloader{path: path, service: service}
+}
+
+// Reload validates the questions file and merges it in if it differs from the
+// file the stored questions were loaded from. It returns the version of the
+// stored questions afterwards.
+func (r *QuestionReloader) Reload() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read questions file: %w", err)
+	}
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime, r.size = info.ModTime(), info.Size()
+	}
+
+	sum := sha256.Sum256(data)
+	source := hex.EncodeToString(sum[:])
+
+	current, err := r.service.GetQuestionsSource()
+	if err != nil {
+		return "", fmt.Errorf("error getting questions source: %w", err)
+	}
+	if current == source {
+		return r.service.GetQuestionsVersion()
+	}
+
+	questions, err := decodeQuestionsStrict(data)
+	if err != nil {
+		return "", err
+	}
+	for _, issue := range LintQuestions(questions) {
+		if issue.Severity == LintError {
+			return "", fmt.Errorf("questions file rejected: %s", issue)
+		}
+	}
+
+	if err := r.service.MergeQuestions(source, questions); err != nil {
+		return "", fmt.Errorf("error merging questions: %w", err)
+	}
+
+	version, err := r.service.GetQuestionsVersion()
+	if err != nil {
+		return "", err
+	}
+	log.Printf("loaded %d questions from %s as version %s", len(questions), r.path, version)
+	return version, nil
+}
+
+// Watch polls the questions file every interval and reloads it when its
+// modification time or size changes, until the context is cancelled.
+func (r *QuestionReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if _, err := r.Reload(); err != nil {
+				log.Printf("error reloading questions: %s", err)
+			}
+		}
+	}
+}
+
+func (r *QuestionReloader) changed() bool {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		log.Printf("error checking questions file: %s", err)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !info.ModTime().Equal(r.modTime) || info.Size() != r.size
+}
+
+// MergeQuestionsFile merges the questions read from the questions file into
+// the stored questions. fromFile holds the IDs of the stored questions that
+// were loaded from the file and haven't been changed through the admin API
+// since; those are replaced by the file's version, or dropped when the file no
+// longer has them. Every other stored question was created, updated or
+// retired by an admin and is kept as it is, even if the file has a question
+// with the same ID. It returns the merged questions and the IDs of the ones
+// that now come from the file.
+func MergeQuestionsFile(stored []Question, fromFile map[string]bool, file []Question) ([]Question, []string) {
+	merged := make([]Question, 0, len(stored)+len(file))
+	admin := make(map[string]bool)
+	for _, q := range stored {
+		if fromFile[q.ID] {
+			continue
+		}
+		admin[q.ID] = true
+		merged = append(merged, q)
+	}
+
+	var fileIDs []string
+	for _, q := range file {
+		if admin[q.ID] {
+			continue
+		}
+		merged = append(merged, q)
+		fileIDs = append(fileIDs, q.ID)
+	}
+	return merged, fileIDs
+}
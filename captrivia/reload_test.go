@@ -0,0 +1,153 @@
+package captrivia_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQuestionService implements the parts of captrivia.QuestionService used
+// when reloading and drawing questions.
+type fakeQuestionService struct {
+	captrivia.QuestionService
+	mu        sync.Mutex
+	questions []captrivia.Question
+	fromFile  map[string]bool
+	source    string
+	version   int
+}
+
+func (s *fakeQuestionService) GetQuestionSet() (string, []captrivia.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strconv.Itoa(s.version), s.questions, nil
+}
+
+func (s *fakeQuestionService) GetQuestionsVersion() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strconv.Itoa(s.version), nil
+}
+
+func (s *fakeQuestionService) CreateQuestion(q captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.questions = append(s.questions, q)
+	s.version++
+	return nil
+}
+
+func (s *fakeQuestionService) MergeQuestions(source string, questions []captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	merged, fileIDs := captrivia.MergeQuestionsFile(s.questions, s.fromFile, questions)
+	s.questions, s.source = merged, source
+	s.fromFile = make(map[string]bool)
+	for _, id := range fileIDs {
+		s.fromFile[id] = true
+	}
+	s.version++
+	return nil
+}
+
+func (s *fakeQuestionService) GetQuestionsSource() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source, nil
+}
+
+func TestQuestionReloaderWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.json")
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q1","options":["a","b"],"correctIndex":0}]`), 0o644)
+
+	service := &fakeQuestionService{}
+	reloader := captrivia.NewQuestionReloader(path, service)
+	version, err := reloader.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", version)
+
+	bank := captrivia.NewServiceQuestionBank(service)
+	g, err := captrivia.NewGame(gameName, 1, bank, captrivia.QuestionFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", g.BankVersion)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	os.WriteFile(path, []byte(`[{"id":"2","questionText":"q2","options":["a","b"],"correctIndex":1}]`), 0o644)
+	assert.Eventually(t, func() bool {
+		v, _ := bank.Version()
+		return v == "2"
+	}, time.Second, 10*time.Millisecond)
+
+	// games created after the reload draw the new questions, the game created
+	// before it keeps its own
+	next, err := captrivia.NewGame(gameName, 1, bank, captrivia.QuestionFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", next.BankVersion)
+	assert.Equal(t, "2", next.CurrentQuestion().ID)
+	assert.Equal(t, "1", g.CurrentQuestion().ID)
+}
+
+func TestQuestionReloaderAfterAdminCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.json")
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q1","options":["a","b"],"correctIndex":0}]`), 0o644)
+
+	service := &fakeQuestionService{}
+	reloader := captrivia.NewQuestionReloader(path, service)
+	_, err := reloader.Reload()
+	assert.Nil(t, err)
+
+	service.CreateQuestion(captrivia.Question{ID: "admin", QuestionText: "admin", Options: []string{"a", "b"}})
+
+	os.WriteFile(path, []byte(`[{"id":"2","questionText":"q2","options":["a","b"],"correctIndex":1}]`), 0o644)
+	version, err := reloader.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, "3", version)
+
+	ids := make(map[string]bool)
+	for _, q := range service.questions {
+		ids[q.ID] = true
+	}
+	assert.Equal(t, map[string]bool{"admin": true, "2": true}, ids)
+}
+
+func TestMergeQuestionsFile(t *testing.T) {
+	stored := []captrivia.Question{
+		{ID: "file", QuestionText: "old"},
+		{ID: "gone", QuestionText: "gone"},
+		{ID: "edited", QuestionText: "edited by an admin"},
+		{ID: "retired", QuestionText: "retired", Retired: true},
+		{ID: "admin", QuestionText: "created by an admin"},
+	}
+	fromFile := map[string]bool{"file": true, "gone": true}
+	file := []captrivia.Question{
+		{ID: "file", QuestionText: "new"},
+		{ID: "edited", QuestionText: "from the file"},
+		{ID: "retired", QuestionText: "from the file"},
+		{ID: "added", QuestionText: "added"},
+	}
+
+	merged, fileIDs := captrivia.MergeQuestionsFile(stored, fromFile, file)
+
+	byID := make(map[string]captrivia.Question)
+	for _, q := range merged {
+		byID[q.ID] = q
+	}
+	assert.Equal(t, 5, len(merged))
+	assert.Equal(t, "new", byID["file"].QuestionText)
+	assert.Equal(t, "edited by an admin", byID["edited"].QuestionText)
+	assert.True(t, byID["retired"].Retired)
+	assert.Equal(t, "created by an admin", byID["admin"].QuestionText)
+	assert.Equal(t, "added", byID["added"].QuestionText)
+	assert.NotContains(t, byID, "gone")
+	assert.ElementsMatch(t, []string{"file", "added"}, fileIDs)
+}
@@ -0,0 +1,39 @@
+package captrivia
+
+// QuestionReveal is shown to players once a question has ended, telling them
+// the correct answer and how everyone answered.
+type QuestionReveal struct {
+	QuestionID     string   `json:"id"`
+	CorrectIndex   int      `json:"correct_index"`
+	Explanation    string   `json:"explanation,omitempty"`
+	PickCounts     []int    `json:"pick_counts"`     // accepted answers for each option
+	CorrectPlayers []string `json:"correct_players"` // in the order they answered
+}
+
+// Reveal returns the reveal of the current question, built from the answers
+// accepted for it.
+func (g *Game) Reveal() QuestionReveal {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	q := g.questions[g.currentQuestionIndex]
+	reveal := QuestionReveal{
+		QuestionID:     q.ID,
+		CorrectIndex:   q.CorrectIndex,
+		Explanation:    q.Explanation,
+		PickCounts:     make([]int, len(q.Options)),
+		CorrectPlayers: []string{},
+	}
+	for _, a := range g.answers {
+		if !a.Accepted || a.QuestionID != q.ID {
+			continue
+		}
+		if a.Index >= 0 && a.Index < len(reveal.PickCounts) {
+			reveal.PickCounts[a.Index]++
+		}
+		if a.Correct {
+			reveal.CorrectPlayers = append(reveal.CorrectPlayers, a.Player)
+		}
+	}
+	return reveal
+}
@@ -0,0 +1,37 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReveal(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	g.AddPlayer("player 3")
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	g.AnswerQuestion("player 2", q.ID, wrongIndex, now, 5*time.Second)
+	g.AnswerQuestion("player 1", q.ID, q.CorrectIndex, now, 5*time.Second)
+	// rejected answers are not counted
+	g.AnswerQuestion("player 2", q.ID, q.CorrectIndex, now, 5*time.Second)
+	g.AnswerQuestion("player 3", "previous question", q.CorrectIndex, now, 5*time.Second)
+
+	reveal := g.Reveal()
+	assert.Equal(t, q.ID, reveal.QuestionID)
+	assert.Equal(t, q.CorrectIndex, reveal.CorrectIndex)
+	assert.Equal(t, q.Explanation, reveal.Explanation)
+	assert.Len(t, reveal.PickCounts, len(q.Options))
+	assert.Equal(t, 1, reveal.PickCounts[q.CorrectIndex])
+	assert.Equal(t, 1, reveal.PickCounts[wrongIndex])
+	assert.Equal(t, []string{"player 1"}, reveal.CorrectPlayers)
+}
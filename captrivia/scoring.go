@@ -0,0 +1,137 @@
+package captrivia
+
+import (
+	"fmt"
+	"time"
+)
+
+type ScoringMode string
+
+const (
+	ScoringModeFirstCorrect ScoringMode = "first_correct"
+	ScoringModeAllCorrect   ScoringMode = "all_correct"
+	ScoringModeTimeDecay    ScoringMode = "time_decay"
+	ScoringModeStreak       ScoringMode = "streak"
+	ScoringModeNegative     ScoringMode = "negative"
+)
+
+const (
+	timeDecayMaxPoints = 1000
+	timeDecayMinPoints = 100
+	maxStreakBonus     = 3
+)
+
+// ScoredAnswer is the information a ScoringStrategy has available when
+// awarding points for an answer.
+type ScoredAnswer struct {
+	Player           string
+	Correct          bool
+	Elapsed          time.Duration // time since the question was displayed
+	QuestionDuration time.Duration
+	Streak           int // consecutive correct answers, including this one
+}
+
+// ScoringStrategy decides how many points an answer is worth and whether a
+// correct answer closes the question for every other player.
+type ScoringStrategy interface {
+	Points(a ScoredAnswer) int
+	ClosesOnCorrect() bool
+}
+
+// AnswerOutcome is the result of scoring a player's answer. Answers are not
+// accepted from players that already answered the question correctly.
+type AnswerOutcome struct {
+	Accepted      bool
+	Rejection     AnswerRejection // why the answer was not accepted
+	Points        int
+	CloseQuestion bool
+}
+
+func NewScoringStrategy(mode ScoringMode) (ScoringStrategy, error) {
+	switch mode {
+	case ScoringModeFirstCorrect, "":
+		return firstCorrectScoring{}, nil
+	case ScoringModeAllCorrect:
+		return allCorrectScoring{}, nil
+	case ScoringModeTimeDecay:
+		return timeDecayScoring{}, nil
+	case ScoringModeStreak:
+		return streakScoring{}, nil
+	case ScoringModeNegative:
+		return negativeScoring{}, nil
+	}
+	return nil, fmt.Errorf("unknown scoring mode %q", mode)
+}
+
+// firstCorrectScoring awards a single point to the first player to answer
+// correctly, which ends the question.
+type firstCorrectScoring struct{}
+
+func (firstCorrectScoring) Points(a ScoredAnswer) int {
+	if a.Correct {
+		return 1
+	}
+	return 0
+}
+
+func (firstCorrectScoring) ClosesOnCorrect() bool { return true }
+
+// allCorrectScoring awards a point to every player that answers correctly
+// before the question expires.
+type allCorrectScoring struct{}
+
+func (allCorrectScoring) Points(a ScoredAnswer) int {
+	if a.Correct {
+		return 1
+	}
+	return 0
+}
+
+func (allCorrectScoring) ClosesOnCorrect() bool { return false }
+
+// timeDecayScoring awards more points the faster a correct answer arrives,
+// decaying linearly from timeDecayMaxPoints to timeDecayMinPoints over the
+// question duration.
+type timeDecayScoring struct{}
+
+func (timeDecayScoring) Points(a ScoredAnswer) int {
+	if !a.Correct {
+		return 0
+	}
+	if a.QuestionDuration <= 0 || a.Elapsed >= a.QuestionDuration {
+		return timeDecayMinPoints
+	}
+	if a.Elapsed < 0 {
+		return timeDecayMaxPoints
+	}
+	remaining := 1 - float64(a.Elapsed)/float64(a.QuestionDuration)
+	return timeDecayMinPoints + int(remaining*float64(timeDecayMaxPoints-timeDecayMinPoints))
+}
+
+func (timeDecayScoring) ClosesOnCorrect() bool { return false }
+
+// streakScoring awards a point per correct answer plus a bonus point for each
+// consecutive correct answer before it, up to maxStreakBonus.
+type streakScoring struct{}
+
+func (streakScoring) Points(a ScoredAnswer) int {
+	if !a.Correct {
+		return 0
+	}
+	return 1 + min(a.Streak-1, maxStreakBonus)
+}
+
+func (streakScoring) ClosesOnCorrect() bool { return false }
+
+// negativeScoring awards a point for a correct answer and takes one away for
+// every wrong answer.
+type negativeScoring struct{}
+
+func (negativeScoring) Points(a ScoredAnswer) int {
+	if a.Correct {
+		return 1
+	}
+	return -1
+}
+
+func (negativeScoring) ClosesOnCorrect() bool { return false }
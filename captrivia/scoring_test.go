@@ -0,0 +1,122 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScoringStrategy(t *testing.T) {
+	modes := []captrivia.ScoringMode{
+		"",
+		captrivia.ScoringModeFirstCorrect,
+		captrivia.ScoringModeAllCorrect,
+		captrivia.ScoringModeTimeDecay,
+		captrivia.ScoringModeStreak,
+		captrivia.ScoringModeNegative,
+	}
+	for _, mode := range modes {
+		_, err := captrivia.NewScoringStrategy(mode)
+		assert.NoError(t, err, mode)
+	}
+
+	_, err := captrivia.NewScoringStrategy("fastest_finger")
+	assert.Error(t, err)
+}
+
+func TestScoringPoints(t *testing.T) {
+	duration := 10 * time.Second
+	correct := captrivia.ScoredAnswer{Correct: true, Elapsed: 0, QuestionDuration: duration, Streak: 1}
+	wrong := captrivia.ScoredAnswer{Correct: false, Elapsed: 0, QuestionDuration: duration}
+
+	firstCorrect, _ := captrivia.NewScoringStrategy(captrivia.ScoringModeFirstCorrect)
+	assert.Equal(t, 1, firstCorrect.Points(correct))
+	assert.Equal(t, 0, firstCorrect.Points(wrong))
+	assert.True(t, firstCorrect.ClosesOnCorrect())
+
+	allCorrect, _ := captrivia.NewScoringStrategy(captrivia.ScoringModeAllCorrect)
+	assert.Equal(t, 1, allCorrect.Points(correct))
+	assert.False(t, allCorrect.ClosesOnCorrect())
+
+	timeDecay, _ := captrivia.NewScoringStrategy(captrivia.ScoringModeTimeDecay)
+	assert.Equal(t, 1000, timeDecay.Points(correct))
+	half := correct
+	half.Elapsed = 5 * time.Second
+	assert.Equal(t, 550, timeDecay.Points(half))
+	late := correct
+	late.Elapsed = 15 * time.Second
+	assert.Equal(t, 100, timeDecay.Points(late))
+	assert.Equal(t, 0, timeDecay.Points(wrong))
+
+	streak, _ := captrivia.NewScoringStrategy(captrivia.ScoringModeStreak)
+	assert.Equal(t, 1, streak.Points(correct))
+	third := correct
+	third.Streak = 3
+	assert.Equal(t, 3, streak.Points(third))
+	tenth := correct
+	tenth.Streak = 10
+	assert.Equal(t, 4, streak.Points(tenth))
+
+	negative, _ := captrivia.NewScoringStrategy(captrivia.ScoringModeNegative)
+	assert.Equal(t, 1, negative.Points(correct))
+	assert.Equal(t, -1, negative.Points(wrong))
+}
+
+func TestScoreAnswerFirstCorrect(t *testing.T) {
+	g, err := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	outcome := g.ScoreAnswer("player 1", false, now, time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.False(t, outcome.CloseQuestion)
+
+	outcome = g.ScoreAnswer("player 2", true, now, time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.True(t, outcome.CloseQuestion)
+	assert.Equal(t, 1, g.Scores["player 2"])
+}
+
+func TestScoreAnswerStreak(t *testing.T) {
+	g, err := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, g.SetScoringMode(captrivia.ScoringModeStreak))
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	now := time.Now()
+
+	// player 1 answers three in a row, player 2 misses the second question
+	for i := 0; i < 3; i++ {
+		g.QuestionDisplayed(now)
+		g.ScoreAnswer("player 1", true, now, time.Second)
+		if i != 1 {
+			g.ScoreAnswer("player 2", true, now, time.Second)
+		}
+		// repeated answers after a correct one are not accepted
+		outcome := g.ScoreAnswer("player 1", true, now, time.Second)
+		assert.False(t, outcome.Accepted)
+		g.GoToNextQuestion()
+	}
+
+	assert.Equal(t, 1+2+3, g.Scores["player 1"])
+	assert.Equal(t, 1+1, g.Scores["player 2"])
+}
+
+func TestSetScoringModeInvalid(t *testing.T) {
+	g, err := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, g.SetScoringMode("fastest_finger"))
+	assert.Equal(t, captrivia.ScoringModeFirstCorrect, g.ScoringMode)
+}
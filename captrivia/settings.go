@@ -0,0 +1,229 @@
+package captrivia
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// bounds on the settings a player may choose for a game
+const (
+	MaxQuestionCount      = 50
+	MinQuestionSeconds    = 5
+	MaxQuestionSeconds    = 120
+	MinCountdownSeconds   = 1
+	MaxCountdownSeconds   = 30
+	MaxRevealSeconds      = 30
+	MaxPlayersLimit       = 50
+	MaxPasswordLength     = 64
+	MaxAnswersPerQuestion = 10
+)
+
+// DefaultQuestionCount is the number of questions drawn for a game created
+// without a question_count.
+const DefaultQuestionCount = 10
+
+// NoReveal is the reveal_seconds of a game that moves straight on from each
+// question without revealing its answer. Zero takes the server's default.
+const NoReveal = -1
+
+// GameSettings are the settings a game is created with. Zero values are
+// filled in with the server's defaults, or keep the current value when a game
+// is reconfigured. A game is public unless Private is set, and only changes
+// its privacy or loses its password when asked to explicitly.
+type GameSettings struct {
+	QuestionCount      int         `json:"question_count"`
+	Mode               GameMode    `json:"mode,omitempty"`
+	Lives              int         `json:"lives,omitempty"` // survival games only, zero starts players with DefaultLives
+	QuestionSeconds    int         `json:"question_seconds,omitempty"`
+	CountdownSeconds   int         `json:"countdown_seconds,omitempty"`
+	RevealSeconds      int         `json:"reveal_seconds,omitempty"`       // time the answer is shown for once a question ends, NoReveal skips it
+	MaxPlayers         int         `json:"max_players,omitempty"`          // zero places no limit on players
+	AnswersPerQuestion int         `json:"answers_per_question,omitempty"` // zero allows each player one answer
+	ScoringMode        ScoringMode `json:"scoring_mode,omitempty"`
+	StartPolicy        StartPolicy `json:"start_policy"`
+	Private            *bool       `json:"private,omitempty"`
+	Password           string      `json:"password,omitempty"`       // only ever sent by the player, the game keeps a hash of it
+	ClearPassword      bool        `json:"clear_password,omitempty"` // removes the password of a game that stays private
+	Teams              []string    `json:"teams,omitempty"`          // names of the teams of a team game
+	TeamScoring        TeamScoring `json:"team_scoring,omitempty"`
+	QuestionFilter                 // categories and difficulty_mix the questions are drawn from
+}
+
+func (s GameSettings) Validate() error {
+	if s.QuestionCount < 0 || s.QuestionCount > MaxQuestionCount {
+		return fmt.Errorf("question_count must be between 1 and %d (0 for the default), got %d", MaxQuestionCount, s.QuestionCount)
+	}
+	if s.QuestionSeconds != 0 && (s.QuestionSeconds < MinQuestionSeconds || s.QuestionSeconds > MaxQuestionSeconds) {
+		return fmt.Errorf("question_seconds must be between %d and %d, got %d", MinQuestionSeconds, MaxQuestionSeconds, s.QuestionSeconds)
+	}
+	if s.CountdownSeconds != 0 && (s.CountdownSeconds < MinCountdownSeconds || s.CountdownSeconds > MaxCountdownSeconds) {
+		return fmt.Errorf("countdown_seconds must be between %d and %d, got %d", MinCountdownSeconds, MaxCountdownSeconds, s.CountdownSeconds)
+	}
+	if s.RevealSeconds < NoReveal || s.RevealSeconds > MaxRevealSeconds {
+		return fmt.Errorf("reveal_seconds must be between 1 and %d (0 for the default, %d for no reveal), got %d", MaxRevealSeconds, NoReveal, s.RevealSeconds)
+	}
+	if s.MaxPlayers < 0 || s.MaxPlayers > MaxPlayersLimit {
+		return fmt.Errorf("max_players must be between 1 and %d (0 for no limit), got %d", MaxPlayersLimit, s.MaxPlayers)
+	}
+	if s.AnswersPerQuestion < 0 || s.AnswersPerQuestion > MaxAnswersPerQuestion {
+		return fmt.Errorf("answers_per_question must be between 1 and %d (0 for the default), got %d", MaxAnswersPerQuestion, s.AnswersPerQuestion)
+	}
+	if len(s.Password) > MaxPasswordLength {
+		return fmt.Errorf("password can not be longer than %d characters", MaxPasswordLength)
+	}
+	if s.Password != "" && s.ClearPassword {
+		return fmt.Errorf("password can not be set and cleared at once")
+	}
+	if err := validateMode(s.Mode, s.Lives); err != nil {
+		return err
+	}
+	if err := validateTeams(s.Teams, s.TeamScoring); err != nil {
+		return err
+	}
+	if _, err := NewScoringStrategy(s.ScoringMode); err != nil {
+		return err
+	}
+	return s.StartPolicy.Validate()
+}
+
+// update returns the settings with every non-zero setting of u applied.
+// Privacy is only changed when u sets it, and the password when u sets or
+// clears it.
+func (s GameSettings) update(u GameSettings) GameSettings {
+	if u.QuestionCount != 0 {
+		s.QuestionCount = u.QuestionCount
+	}
+	if u.Mode != "" {
+		s.Mode = u.Mode
+	}
+	if u.Lives != 0 {
+		s.Lives = u.Lives
+	}
+	if u.QuestionSeconds != 0 {
+		s.QuestionSeconds = u.QuestionSeconds
+	}
+	if u.CountdownSeconds != 0 {
+		s.CountdownSeconds = u.CountdownSeconds
+	}
+	if u.RevealSeconds != 0 {
+		s.RevealSeconds = u.RevealSeconds
+	}
+	if u.MaxPlayers != 0 {
+		s.MaxPlayers = u.MaxPlayers
+	}
+	if u.AnswersPerQuestion != 0 {
+		s.AnswersPerQuestion = u.AnswersPerQuestion
+	}
+	if u.ScoringMode != "" {
+		s.ScoringMode = u.ScoringMode
+	}
+	if u.StartPolicy.Mode != "" {
+		s.StartPolicy = u.StartPolicy
+	}
+	if len(u.Teams) > 0 {
+		s.Teams = u.Teams
+	}
+	if u.TeamScoring != "" {
+		s.TeamScoring = u.TeamScoring
+	}
+	if len(u.Categories) > 0 || len(u.DifficultyMix) > 0 {
+		s.QuestionFilter = u.QuestionFilter
+	}
+	if u.Private != nil {
+		s.Private = u.Private
+	}
+	s.Password = u.Password
+	s.ClearPassword = u.ClearPassword
+	return s
+}
+
+// IsPrivate reports whether the settings are for a private game.
+func (s GameSettings) IsPrivate() bool {
+	return s.Private != nil && *s.Private
+}
+
+// Settings returns the game's current settings. The password is never
+// returned, only whether the game has one.
+func (g *Game) Settings() GameSettings {
+	private := g.Private
+	return GameSettings{
+		QuestionCount:      g.QuestionCount,
+		Mode:               g.Mode,
+		Lives:              g.Lives,
+		QuestionSeconds:    g.QuestionSeconds,
+		CountdownSeconds:   g.CountdownSeconds,
+		RevealSeconds:      g.RevealSeconds,
+		MaxPlayers:         g.MaxPlayers,
+		AnswersPerQuestion: g.AnswersPerQuestion,
+		ScoringMode:        g.ScoringMode,
+		StartPolicy:        g.StartPolicy,
+		Private:            &private,
+		Teams:              g.Teams,
+		TeamScoring:        g.TeamScoring,
+		QuestionFilter:     QuestionFilter{Categories: g.Categories},
+	}
+}
+
+// ApplySettings sets everything but the questions of the game from the
+// settings. The questions are drawn with the settings by NewGame or
+// Reconfigure. Settings chosen by players should be validated first, the
+// server's own defaults are not held to the same bounds.
+func (g *Game) ApplySettings(s GameSettings) error {
+	if err := g.SetScoringMode(s.ScoringMode); err != nil {
+		return err
+	}
+	if err := g.SetStartPolicy(s.StartPolicy); err != nil {
+		return err
+	}
+
+	g.QuestionSeconds = s.QuestionSeconds
+	g.CountdownSeconds = s.CountdownSeconds
+	g.RevealSeconds = s.RevealSeconds
+	g.MaxPlayers = s.MaxPlayers
+	g.AnswersPerQuestion = s.AnswersPerQuestion
+	g.Categories = s.Categories
+	g.setTeams(s.Teams, s.TeamScoring)
+	g.setMode(s.Mode, s.Lives)
+	g.Private = s.IsPrivate()
+	if s.Password != "" {
+		g.passwordHash = hashPassword(s.Password)
+	} else if s.ClearPassword || !g.Private {
+		g.passwordHash = ""
+	}
+	return nil
+}
+
+// HasPassword reports whether players need a password to join the game.
+func (g *Game) HasPassword() bool {
+	return g.passwordHash != ""
+}
+
+// CheckPassword reports whether the password lets a player join the game.
+// Every password is accepted by games without one.
+func (g *Game) CheckPassword(password string) bool {
+	if g.passwordHash == "" {
+		return true
+	}
+	salt, _, ok := strings.Cut(g.passwordHash, "$")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(saltedHash(salt, password)), []byte(g.passwordHash)) == 1
+}
+
+// hashPassword hashes the password with a random salt, returned as
+// "salt$hash".
+func hashPassword(password string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return saltedHash(hex.EncodeToString(b), password)
+}
+
+func saltedHash(salt string, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return salt + "$" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package captrivia_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameSettingsValidate(t *testing.T) {
+	private := true
+	tests := []struct {
+		name     string
+		settings captrivia.GameSettings
+		ok       bool
+	}{
+		{"defaults", captrivia.GameSettings{QuestionCount: 5}, true},
+		{"every setting", captrivia.GameSettings{QuestionCount: 5, QuestionSeconds: 20, CountdownSeconds: 5, MaxPlayers: 8, ScoringMode: captrivia.ScoringModeStreak, Private: &private, Password: "secret"}, true},
+		{"no reveal", captrivia.GameSettings{QuestionCount: 5, RevealSeconds: captrivia.NoReveal}, true},
+		{"negative reveal", captrivia.GameSettings{QuestionCount: 5, RevealSeconds: captrivia.NoReveal - 1}, false},
+		{"set and clear password", captrivia.GameSettings{QuestionCount: 5, Password: "secret", ClearPassword: true}, false},
+		{"too many questions", captrivia.GameSettings{QuestionCount: captrivia.MaxQuestionCount + 1}, false},
+		{"question too short", captrivia.GameSettings{QuestionCount: 5, QuestionSeconds: captrivia.MinQuestionSeconds - 1}, false},
+		{"question too long", captrivia.GameSettings{QuestionCount: 5, QuestionSeconds: captrivia.MaxQuestionSeconds + 1}, false},
+		{"countdown too long", captrivia.GameSettings{QuestionCount: 5, CountdownSeconds: captrivia.MaxCountdownSeconds + 1}, false},
+		{"negative max players", captrivia.GameSettings{QuestionCount: 5, MaxPlayers: -1}, false},
+		{"too many players", captrivia.GameSettings{QuestionCount: 5, MaxPlayers: captrivia.MaxPlayersLimit + 1}, false},
+		{"unknown scoring mode", captrivia.GameSettings{QuestionCount: 5, ScoringMode: "unknown"}, false},
+		{"bad start policy", captrivia.GameSettings{QuestionCount: 5, StartPolicy: captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady}}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.settings.Validate()
+		if tt.ok {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.Error(t, err, tt.name)
+		}
+	}
+}
+
+func TestGamePassword(t *testing.T) {
+	g := CreateTestGame()
+	assert.False(t, g.HasPassword())
+	assert.True(t, g.CheckPassword("anything"))
+
+	private := true
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{Private: &private, Password: "secret"}))
+	assert.True(t, g.HasPassword())
+	assert.True(t, g.CheckPassword("secret"))
+	assert.False(t, g.CheckPassword("Secret"))
+	assert.False(t, g.CheckPassword(""))
+
+	// the password is never part of the game's JSON or settings
+	b, err := json.Marshal(&g)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "secret")
+	assert.Empty(t, g.Settings().Password)
+
+	// the password survives a restart
+	restored, err := captrivia.RestoreGame(g.Snapshot())
+	assert.NoError(t, err)
+	assert.True(t, restored.CheckPassword("secret"))
+
+	// staying private keeps the password, going public drops it
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{Private: &private}))
+	assert.True(t, g.HasPassword())
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{}))
+	assert.False(t, g.HasPassword())
+}
+
+func TestReconfigureKeepsPrivacy(t *testing.T) {
+	g := CreateTestGame()
+	private := true
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{QuestionCount: questionCount, Private: &private, Password: "secret"}))
+
+	// an update that leaves privacy and the password out keeps both
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{MaxPlayers: 4}, testBank))
+	assert.True(t, g.Private)
+	assert.True(t, g.HasPassword())
+	assert.True(t, g.CheckPassword("secret"))
+	assert.Equal(t, 4, g.MaxPlayers)
+	assert.True(t, *g.Settings().Private)
+
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{Password: "changed"}, testBank))
+	assert.True(t, g.CheckPassword("changed"))
+
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{ClearPassword: true}, testBank))
+	assert.True(t, g.Private)
+	assert.False(t, g.HasPassword())
+
+	public := false
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{Password: "again"}, testBank))
+	assert.NoError(t, g.Reconfigure("", captrivia.GameSettings{Private: &public}, testBank))
+	assert.False(t, g.Private)
+	assert.False(t, g.HasPassword())
+}
+
+func TestToRepositoryGameSettings(t *testing.T) {
+	g := CreateTestGame()
+	private := true
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{
+		QuestionSeconds:  15,
+		CountdownSeconds: 3,
+		MaxPlayers:       4,
+		ScoringMode:      captrivia.ScoringModeTimeDecay,
+		Private:          &private,
+		Password:         "secret",
+		QuestionFilter:   captrivia.QuestionFilter{Categories: []string{"science"}},
+	}))
+
+	rg := g.ToRepositoryGame()
+	assert.Equal(t, 15, rg.QuestionSeconds)
+	assert.Equal(t, 3, rg.CountdownSeconds)
+	assert.Equal(t, 4, rg.MaxPlayers)
+	assert.Equal(t, captrivia.ScoringModeTimeDecay, rg.ScoringMode)
+	assert.Equal(t, []string{"science"}, rg.Categories)
+	assert.True(t, rg.Private)
+	assert.True(t, rg.HasPassword)
+
+	hash := rg.ToHash()
+	assert.Equal(t, "science", hash["categories"])
+	assert.Equal(t, "true", hash["has_password"])
+}
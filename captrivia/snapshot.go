@@ -0,0 +1,158 @@
+package captrivia
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GameSnapshot is the full state of a game, persisted so that games which
+// were in progress when the server stopped can be resumed on startup.
+type GameSnapshot struct {
+	ID                   uuid.UUID         `json:"id"`
+	Name                 string            `json:"name"`
+	QuestionCount        int               `json:"question_count"`
+	ScoringMode          ScoringMode       `json:"scoring_mode"`
+	StartPolicy          StartPolicy       `json:"start_policy"`
+	QuestionSeconds      int               `json:"question_seconds"`
+	CountdownSeconds     int               `json:"countdown_seconds"`
+	RevealSeconds        int               `json:"reveal_seconds"`
+	MaxPlayers           int               `json:"max_players"`
+	AnswersPerQuestion   int               `json:"answers_per_question"`
+	Categories           []string          `json:"categories"`
+	Teams                []string          `json:"teams"`
+	TeamScoring          TeamScoring       `json:"team_scoring"`
+	Mode                 GameMode          `json:"mode"`
+	Lives                int               `json:"lives"`
+	Ranked               bool              `json:"ranked"`
+	Daily                string            `json:"daily"`
+	Private              bool              `json:"private"`
+	InviteCode           string            `json:"invite_code"`
+	PasswordHash         string            `json:"password_hash"`
+	BankVersion          string            `json:"bank_version"`
+	Host                 string            `json:"host"`
+	JoinOrder            []string          `json:"join_order"`
+	State                GameState         `json:"state"`
+	Questions            []Question        `json:"questions"`
+	CurrentQuestionIndex int               `json:"current_question_index"`
+	QuestionDisplayedAt  time.Time         `json:"question_displayed_at"`
+	Deadline             time.Time         `json:"deadline"`
+	PausedFrom           GameState         `json:"paused_from,omitempty"`
+	PausedAt             time.Time         `json:"paused_at"`
+	PlayersReady         map[string]bool   `json:"players_ready"`
+	Scores               map[string]int    `json:"scores"`
+	Streaks              map[string]int    `json:"streaks"`
+	CorrectPlayers       map[string]bool   `json:"correct_players"`
+	AnswerCounts         map[string]int    `json:"answer_counts"`
+	PlayerTeams          map[string]string `json:"player_teams"`
+	TeamScores           map[string]int    `json:"team_scores"`
+	TeamsScored          map[string]bool   `json:"teams_scored"`
+	LivesLost            map[string]int    `json:"lives_lost"`
+	QuestionsShown       map[string]int    `json:"questions_shown"`
+	Answers              []AnswerRecord    `json:"answers"`
+}
+
+// Snapshot returns a copy of the game's state that is safe to serialize while
+// the game continues to run.
+func (g *Game) Snapshot() GameSnapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return GameSnapshot{
+		ID:                   g.ID,
+		Name:                 g.Name,
+		QuestionCount:        g.QuestionCount,
+		ScoringMode:          g.ScoringMode,
+		StartPolicy:          g.StartPolicy,
+		QuestionSeconds:      g.QuestionSeconds,
+		CountdownSeconds:     g.CountdownSeconds,
+		RevealSeconds:        g.RevealSeconds,
+		MaxPlayers:           g.MaxPlayers,
+		AnswersPerQuestion:   g.AnswersPerQuestion,
+		Categories:           slices.Clone(g.Categories),
+		Teams:                slices.Clone(g.Teams),
+		TeamScoring:          g.TeamScoring,
+		Mode:                 g.Mode,
+		Lives:                g.Lives,
+		Ranked:               g.Ranked,
+		Daily:                g.Daily,
+		Private:              g.Private,
+		InviteCode:           g.InviteCode,
+		PasswordHash:         g.passwordHash,
+		BankVersion:          g.BankVersion,
+		Host:                 g.Host,
+		JoinOrder:            slices.Clone(g.joinOrder),
+		State:                g.State,
+		Questions:            append([]Question(nil), g.questions...),
+		CurrentQuestionIndex: g.currentQuestionIndex,
+		QuestionDisplayedAt:  g.questionDisplayedAt,
+		Deadline:             g.deadline,
+		PausedFrom:           g.pausedFrom,
+		PausedAt:             g.pausedAt,
+		PlayersReady:         maps.Clone(g.PlayersReady),
+		Scores:               maps.Clone(g.Scores),
+		Streaks:              maps.Clone(g.streaks),
+		CorrectPlayers:       maps.Clone(g.correctPlayers),
+		AnswerCounts:         maps.Clone(g.answerCounts),
+		PlayerTeams:          maps.Clone(g.playerTeams),
+		TeamScores:           maps.Clone(g.teamScores),
+		TeamsScored:          maps.Clone(g.teamsScored),
+		LivesLost:            maps.Clone(g.livesLost),
+		QuestionsShown:       maps.Clone(g.questionsShown),
+		Answers:              append([]AnswerRecord(nil), g.answers...),
+	}
+}
+
+// RestoreGame rebuilds a game from a snapshot.
+func RestoreGame(s GameSnapshot) (*Game, error) {
+	game := newGame(s.Name, s.QuestionCount)
+	game.ID = s.ID
+	game.State = s.State
+	game.BankVersion = s.BankVersion
+	game.QuestionSeconds = s.QuestionSeconds
+	game.CountdownSeconds = s.CountdownSeconds
+	game.RevealSeconds = s.RevealSeconds
+	game.MaxPlayers = s.MaxPlayers
+	game.AnswersPerQuestion = s.AnswersPerQuestion
+	game.Categories = s.Categories
+	game.Teams = s.Teams
+	game.TeamScoring = s.TeamScoring
+	game.setMode(s.Mode, s.Lives)
+	game.Ranked = s.Ranked
+	game.Daily = s.Daily
+	game.Private = s.Private
+	game.InviteCode = s.InviteCode
+	game.passwordHash = s.PasswordHash
+	game.Host = s.Host
+	game.joinOrder = s.JoinOrder
+	game.questions = s.Questions
+	game.currentQuestionIndex = s.CurrentQuestionIndex
+	game.questionDisplayedAt = s.QuestionDisplayedAt
+	game.deadline = s.Deadline
+	game.pausedFrom = s.PausedFrom
+	game.pausedAt = s.PausedAt
+	game.answers = s.Answers
+
+	if err := game.SetScoringMode(s.ScoringMode); err != nil {
+		return nil, err
+	}
+	if err := game.SetStartPolicy(s.StartPolicy); err != nil {
+		return nil, err
+	}
+
+	maps.Copy(game.PlayersReady, s.PlayersReady)
+	maps.Copy(game.Scores, s.Scores)
+	maps.Copy(game.streaks, s.Streaks)
+	maps.Copy(game.correctPlayers, s.CorrectPlayers)
+	maps.Copy(game.answerCounts, s.AnswerCounts)
+	maps.Copy(game.playerTeams, s.PlayerTeams)
+	maps.Copy(game.teamScores, s.TeamScores)
+	maps.Copy(game.teamsScored, s.TeamsScored)
+	maps.Copy(game.livesLost, s.LivesLost)
+	maps.Copy(game.questionsShown, s.QuestionsShown)
+	game.PlayerCount = len(game.PlayersReady)
+
+	return game, nil
+}
@@ -0,0 +1,70 @@
+package captrivia_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	g, err := captrivia.NewGame(gameName, questionCount, testBank, captrivia.QuestionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, g.SetScoringMode(captrivia.ScoringModeStreak))
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	g.PlayerReady("player 1")
+	g.State = captrivia.GameStateQuestion
+
+	now := time.Now()
+	g.QuestionDisplayed(now)
+	g.AnswerQuestion("player 1", g.CurrentQuestion().ID, g.CurrentQuestion().CorrectIndex, now.Add(time.Second), 5*time.Second)
+	g.GoToNextQuestion()
+	deadline := now.Add(5 * time.Second)
+	g.SetDeadline(deadline)
+
+	b, err := json.Marshal(g.Snapshot())
+	assert.NoError(t, err)
+
+	var snapshot captrivia.GameSnapshot
+	assert.NoError(t, json.Unmarshal(b, &snapshot))
+
+	restored, err := captrivia.RestoreGame(snapshot)
+	assert.NoError(t, err)
+
+	assert.Equal(t, g.ID, restored.ID)
+	assert.Equal(t, g.Name, restored.Name)
+	assert.Equal(t, captrivia.GameStateQuestion, restored.State)
+	assert.Equal(t, captrivia.ScoringModeStreak, restored.ScoringMode)
+	assert.Equal(t, 1, restored.CurrentIndex())
+	assert.Equal(t, g.CurrentQuestion(), restored.CurrentQuestion())
+	assert.Equal(t, 2, restored.PlayerCount)
+	assert.Equal(t, map[string]bool{"player 1": true, "player 2": false}, restored.PlayersReady)
+	assert.Equal(t, 1, restored.Scores["player 1"])
+	assert.Equal(t, 1, len(restored.Answers()))
+	assert.True(t, deadline.Equal(restored.Deadline()))
+	assert.True(t, restored.InProgress())
+
+	// the host passes on in the order players joined
+	assert.Equal(t, "player 1", restored.Host)
+	restored.RemovePlayer("player 1")
+	assert.Equal(t, "player 2", restored.Host)
+}
+
+func TestAddPlayerKeepsSeat(t *testing.T) {
+	g := CreateTestGame()
+	g.AddPlayer("player 1")
+	g.PlayerReady("player 1")
+	g.Scores["player 1"] = 3
+
+	g.AddPlayer("player 1")
+
+	assert.Equal(t, 1, g.PlayerCount)
+	assert.True(t, g.PlayersReady["player 1"])
+	assert.Equal(t, 3, g.Scores["player 1"])
+	assert.True(t, g.HasPlayer("player 1"))
+}
@@ -0,0 +1,44 @@
+package captrivia
+
+import "slices"
+
+// AddSpectator lets a player watch the game without taking a seat. Players
+// that already have a seat keep it.
+func (g *Game) AddSpectator(player string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.PlayersReady[player]; ok {
+		return
+	}
+	g.spectators[player] = true
+}
+
+func (g *Game) RemoveSpectator(player string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.spectators, player)
+}
+
+func (g *Game) IsSpectator(player string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.spectators[player]
+}
+
+func (g *Game) SpectatorCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.spectators)
+}
+
+// SpectatorNames returns the spectators of the game in name order.
+func (g *Game) SpectatorNames() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.spectators))
+	for name := range g.spectators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,101 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+)
+
+type StartPolicyMode string
+
+const (
+	StartPolicyManual   StartPolicyMode = "manual"    // the host starts the game whenever they like
+	StartPolicyAllReady StartPolicyMode = "all_ready" // the host starts the game once every player is ready
+	StartPolicyMinReady StartPolicyMode = "min_ready" // the host starts the game once MinReady players are ready
+	StartPolicyAuto     StartPolicyMode = "auto"      // the game starts itself once every player is ready or the lobby timer expires
+)
+
+var ErrNotEnoughReady = errors.New("not enough players are ready")
+
+// StartPolicy decides when a game waiting in its lobby may start. The zero
+// value is the manual policy.
+type StartPolicy struct {
+	Mode         StartPolicyMode `json:"mode"`
+	MinReady     int             `json:"min_ready,omitempty"`
+	LobbySeconds int             `json:"lobby_seconds,omitempty"` // auto policy only, zero waits for every player to be ready
+}
+
+func (p StartPolicy) Validate() error {
+	switch p.Mode {
+	case StartPolicyManual, StartPolicyAllReady, "":
+	case StartPolicyMinReady:
+		if p.MinReady < 1 {
+			return fmt.Errorf("min_ready must be at least 1, got %d", p.MinReady)
+		}
+	case StartPolicyAuto:
+		if p.LobbySeconds < 0 {
+			return fmt.Errorf("lobby_seconds can not be negative, got %d", p.LobbySeconds)
+		}
+	default:
+		return fmt.Errorf("unknown start policy %q", p.Mode)
+	}
+	return nil
+}
+
+// CanStart returns ErrNotEnoughReady if the host may not yet start a game with
+// ready of its players ready. The host of an auto game may start it early.
+func (p StartPolicy) CanStart(ready int, players int) error {
+	switch p.Mode {
+	case StartPolicyAllReady:
+		if players == 0 || ready < players {
+			return fmt.Errorf("%w: %d of %d players are ready", ErrNotEnoughReady, ready, players)
+		}
+	case StartPolicyMinReady:
+		if ready < p.MinReady {
+			return fmt.Errorf("%w: %d of the %d needed players are ready", ErrNotEnoughReady, ready, p.MinReady)
+		}
+	}
+	return nil
+}
+
+// AutoStart reports whether an auto game should start now that ready of its
+// players are ready.
+func (p StartPolicy) AutoStart(ready int, players int) bool {
+	return p.Mode == StartPolicyAuto && players > 0 && ready == players
+}
+
+// HasLobbyTimer reports whether the game starts itself when its lobby timer
+// expires.
+func (p StartPolicy) HasLobbyTimer() bool {
+	return p.Mode == StartPolicyAuto && p.LobbySeconds > 0
+}
+
+func (g *Game) SetStartPolicy(p StartPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if p.Mode == "" {
+		p.Mode = StartPolicyManual
+	}
+	g.StartPolicy = p
+	return nil
+}
+
+// ReadyCount is the number of seated players that are ready.
+func (g *Game) ReadyCount() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ready := 0
+	for _, r := range g.PlayersReady {
+		if r {
+			ready++
+		}
+	}
+	return ready
+}
+
+// CanStart returns ErrNotEnoughReady if the game's start policy does not allow
+// the host to start it yet.
+func (g *Game) CanStart() error {
+	return g.StartPolicy.CanStart(g.ReadyCount(), g.PlayerCount)
+}
@@ -0,0 +1,76 @@
+package captrivia_test
+
+import (
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartPolicyValidate(t *testing.T) {
+	assert.NoError(t, captrivia.StartPolicy{}.Validate())
+	assert.NoError(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}.Validate())
+	assert.NoError(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady, MinReady: 2}.Validate())
+	assert.NoError(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyAuto, LobbySeconds: 30}.Validate())
+
+	assert.Error(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady}.Validate())
+	assert.Error(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyAuto, LobbySeconds: -1}.Validate())
+	assert.Error(t, captrivia.StartPolicy{Mode: "whenever"}.Validate())
+}
+
+func TestStartPolicyCanStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  captrivia.StartPolicy
+		ready   int
+		players int
+		ok      bool
+	}{
+		{"manual nobody ready", captrivia.StartPolicy{Mode: captrivia.StartPolicyManual}, 0, 3, true},
+		{"all ready", captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}, 3, 3, true},
+		{"all ready missing one", captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}, 2, 3, false},
+		{"all ready empty game", captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}, 0, 0, false},
+		{"min ready met", captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady, MinReady: 2}, 2, 4, true},
+		{"min ready not met", captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady, MinReady: 2}, 1, 4, false},
+		{"auto started early", captrivia.StartPolicy{Mode: captrivia.StartPolicyAuto}, 0, 2, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.policy.CanStart(tt.ready, tt.players)
+		if tt.ok {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.ErrorIs(t, err, captrivia.ErrNotEnoughReady, tt.name)
+		}
+	}
+}
+
+func TestStartPolicyAutoStart(t *testing.T) {
+	auto := captrivia.StartPolicy{Mode: captrivia.StartPolicyAuto}
+
+	assert.True(t, auto.AutoStart(2, 2))
+	assert.False(t, auto.AutoStart(1, 2))
+	assert.False(t, auto.AutoStart(0, 0))
+	assert.False(t, captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}.AutoStart(2, 2))
+
+	assert.False(t, auto.HasLobbyTimer())
+	auto.LobbySeconds = 10
+	assert.True(t, auto.HasLobbyTimer())
+}
+
+func TestGameCanStart(t *testing.T) {
+	g := CreateTestGame()
+	assert.Equal(t, captrivia.StartPolicyManual, g.StartPolicy.Mode)
+
+	assert.Error(t, g.SetStartPolicy(captrivia.StartPolicy{Mode: "whenever"}))
+	assert.NoError(t, g.SetStartPolicy(captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}))
+
+	g.AddPlayer("player 1")
+	g.AddPlayer("player 2")
+	g.PlayerReady("player 1")
+	assert.Equal(t, 1, g.ReadyCount())
+	assert.ErrorIs(t, g.CanStart(), captrivia.ErrNotEnoughReady)
+
+	g.PlayerReady("player 2")
+	assert.NoError(t, g.CanStart())
+}
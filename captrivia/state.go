@@ -0,0 +1,100 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// gameStateTransitions lists the states a game may move to from each state.
+// A game runs waiting -> countdown -> question -> reveal -> countdown ... until
+// it ends, and may be paused or aborted while it is running.
+var gameStateTransitions = map[GameState][]GameState{
+	GameStateWaiting:   {GameStateCountdown, GameStateAborted},
+	GameStateCountdown: {GameStateQuestion, GameStatePaused, GameStateEnded, GameStateAborted},
+	GameStateQuestion:  {GameStateReveal, GameStateCountdown, GameStatePaused, GameStateEnded, GameStateAborted},
+	GameStateReveal:    {GameStateCountdown, GameStatePaused, GameStateEnded, GameStateAborted},
+	GameStatePaused:    {GameStateCountdown, GameStateQuestion, GameStateReveal, GameStateAborted},
+	GameStateEnded:     {},
+	GameStateAborted:   {},
+}
+
+var ErrNotPaused = errors.New("game is not paused")
+
+// InvalidTransitionError is returned when a game is asked to move to a state
+// that can not be reached from its current state.
+type InvalidTransitionError struct {
+	From GameState
+	To   GameState
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("game can not move from %s to %s", e.From, e.To)
+}
+
+// CanTransitionTo reports whether a game in state s may move to state to.
+func (s GameState) CanTransitionTo(to GameState) bool {
+	for _, next := range gameStateTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Finished reports whether the game is over, whether it ended or was aborted.
+func (s GameState) Finished() bool {
+	return s == GameStateEnded || s == GameStateAborted
+}
+
+// CurrentState returns the game's state, safe to call while the game runs.
+func (g *Game) CurrentState() GameState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.State
+}
+
+// TransitionTo moves the game to the state, returning an
+// InvalidTransitionError if the transition is not allowed.
+func (g *Game) TransitionTo(to GameState) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.State.CanTransitionTo(to) {
+		return &InvalidTransitionError{From: g.State, To: to}
+	}
+	g.State = to
+	return nil
+}
+
+// Pause stops the clock of a running game. The state it was in is kept so
+// Resume can carry on from where it left off.
+func (g *Game) Pause(now time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.State.CanTransitionTo(GameStatePaused) {
+		return &InvalidTransitionError{From: g.State, To: GameStatePaused}
+	}
+	g.pausedFrom = g.State
+	g.pausedAt = now
+	g.State = GameStatePaused
+	return nil
+}
+
+// Resume returns a paused game to the state it was paused in, moving its
+// deadline and the display time of the current question on by how long it
+// was paused. The state and the time left on it are returned.
+func (g *Game) Resume(now time.Time) (GameState, time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.State != GameStatePaused {
+		return g.State, 0, ErrNotPaused
+	}
+	paused := now.Sub(g.pausedAt)
+	g.deadline = g.deadline.Add(paused)
+	g.questionDisplayedAt = g.questionDisplayedAt.Add(paused)
+	g.State = g.pausedFrom
+	return g.State, max(g.deadline.Sub(now), 0), nil
+}
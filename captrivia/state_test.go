@@ -0,0 +1,84 @@
+package captrivia_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameStateTransitions(t *testing.T) {
+	tests := []struct {
+		from    captrivia.GameState
+		to      captrivia.GameState
+		allowed bool
+	}{
+		{captrivia.GameStateWaiting, captrivia.GameStateCountdown, true},
+		{captrivia.GameStateWaiting, captrivia.GameStateQuestion, false},
+		{captrivia.GameStateCountdown, captrivia.GameStateCountdown, false},
+		{captrivia.GameStateCountdown, captrivia.GameStateQuestion, true},
+		{captrivia.GameStateQuestion, captrivia.GameStateReveal, true},
+		{captrivia.GameStateReveal, captrivia.GameStateCountdown, true},
+		{captrivia.GameStateReveal, captrivia.GameStateQuestion, false},
+		{captrivia.GameStateQuestion, captrivia.GameStatePaused, true},
+		{captrivia.GameStatePaused, captrivia.GameStateQuestion, true},
+		{captrivia.GameStatePaused, captrivia.GameStateEnded, false},
+		{captrivia.GameStateCountdown, captrivia.GameStateAborted, true},
+		{captrivia.GameStateEnded, captrivia.GameStateWaiting, false},
+		{captrivia.GameStateAborted, captrivia.GameStateCountdown, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
+
+func TestStartGameTwice(t *testing.T) {
+	g := CreateTestGame()
+
+	assert.Nil(t, g.StartGame())
+	assert.Equal(t, captrivia.GameStateCountdown, g.State)
+	assert.True(t, g.InProgress())
+
+	err := g.StartGame()
+	var transitionErr *captrivia.InvalidTransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, captrivia.GameStateCountdown, transitionErr.From)
+	assert.Equal(t, captrivia.GameStateCountdown, transitionErr.To)
+}
+
+func TestPauseResume(t *testing.T) {
+	g := CreateTestGame()
+
+	// only a running game can be paused
+	assert.Error(t, g.Pause(time.Now()))
+	_, _, err := g.Resume(time.Now())
+	assert.ErrorIs(t, err, captrivia.ErrNotPaused)
+
+	g.State = captrivia.GameStateQuestion
+	displayed := time.Now()
+	g.QuestionDisplayed(displayed)
+	g.SetDeadline(displayed.Add(10 * time.Second))
+
+	pausedAt := displayed.Add(4 * time.Second)
+	assert.NoError(t, g.Pause(pausedAt))
+	assert.Equal(t, captrivia.GameStatePaused, g.State)
+	assert.True(t, g.InProgress())
+	assert.Error(t, g.Pause(pausedAt))
+
+	// answers aren't taken while the game is paused
+	q := g.CurrentQuestion()
+	g.AddPlayer("player")
+	_, outcome := g.AnswerQuestion("player", q.ID, q.CorrectIndex, pausedAt, 10*time.Second)
+	assert.Equal(t, captrivia.AnswerRejectedNotOpen, outcome.Rejection)
+
+	// the time the game was paused for doesn't count against the question
+	state, remaining, err := g.Resume(pausedAt.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, captrivia.GameStateQuestion, state)
+	assert.Equal(t, 6*time.Second, remaining)
+	record, _ := g.AnswerQuestion("player", q.ID, q.CorrectIndex, pausedAt.Add(time.Minute+time.Second), 10*time.Second)
+	assert.Equal(t, int64(5000), record.LatencyMs)
+}
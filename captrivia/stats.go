@@ -0,0 +1,83 @@
+package captrivia
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type LeaderboardSort string
+
+const (
+	LeaderboardSortAccuracy LeaderboardSort = "accuracy"
+	LeaderboardSortCorrect  LeaderboardSort = "correct"
+	LeaderboardSortTotal    LeaderboardSort = "total"
+	LeaderboardSortSpeed    LeaderboardSort = "average_milliseconds"
+)
+
+// PlayerStats holds the answer statistics for a player. Stats collected for a
+// single game are added on to the player's lifetime stats by the GameService.
+type PlayerStats struct {
+	PlayerName        string    `json:"player_name"`
+	CorrectQuestions  int       `json:"correct_questions,string"`
+	AnsweredQuestions int       `json:"answered_questions,string"`
+	TotalQuestions    int       `json:"total_questions,string"` // questions the player was shown, answered or not
+	TimeMilliseconds  int64     `json:"time_milliseconds,string"`
+	LastUpdate        time.Time `json:"last_update"`
+}
+
+// LeaderboardQuery describes which page of the leaderboard to fetch and how
+// it should be ordered.
+type LeaderboardQuery struct {
+	Sort   LeaderboardSort
+	Offset int
+	Limit  int
+}
+
+func (s PlayerStats) MarshalJSON() ([]byte, error) {
+	type Alias PlayerStats
+	return json.Marshal(&struct {
+		Accuracy            float64 `json:"accuracy"`
+		AverageMilliseconds int64   `json:"average_milliseconds"`
+		Alias
+	}{
+		Accuracy:            s.Accuracy(),
+		AverageMilliseconds: s.AverageMilliseconds(),
+		Alias:               (Alias)(s),
+	})
+}
+
+// Add returns the stats with the stats from a game added on.
+func (s PlayerStats) Add(game PlayerStats) PlayerStats {
+	s.CorrectQuestions += game.CorrectQuestions
+	s.AnsweredQuestions += game.AnsweredQuestions
+	s.TotalQuestions += game.TotalQuestions
+	s.TimeMilliseconds += game.TimeMilliseconds
+	return s
+}
+
+// Accuracy is the fraction of the questions shown to the player they got
+// right, questions left unanswered count against them.
+func (s PlayerStats) Accuracy() float64 {
+	if s.TotalQuestions == 0 {
+		return 0
+	}
+	return float64(s.CorrectQuestions) / float64(s.TotalQuestions)
+}
+
+// AverageMilliseconds is the mean time it took the player to answer a
+// question after it was displayed.
+func (s PlayerStats) AverageMilliseconds() int64 {
+	if s.AnsweredQuestions == 0 {
+		return 0
+	}
+	return s.TimeMilliseconds / int64(s.AnsweredQuestions)
+}
+
+func (s LeaderboardSort) Validate() error {
+	switch s {
+	case LeaderboardSortAccuracy, LeaderboardSortCorrect, LeaderboardSortTotal, LeaderboardSortSpeed:
+		return nil
+	}
+	return fmt.Errorf("unknown leaderboard sort %q", s)
+}
@@ -0,0 +1,23 @@
+package captrivia_test
+
+import (
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayerStatsAccuracy(t *testing.T) {
+	// a question left unanswered counts against accuracy but not speed
+	s := captrivia.PlayerStats{CorrectQuestions: 3, AnsweredQuestions: 3, TotalQuestions: 4, TimeMilliseconds: 6000}
+
+	assert.Equal(t, 0.75, s.Accuracy())
+	assert.Equal(t, int64(2000), s.AverageMilliseconds())
+
+	total := s.Add(captrivia.PlayerStats{CorrectQuestions: 1, AnsweredQuestions: 2, TotalQuestions: 4, TimeMilliseconds: 1000})
+	assert.Equal(t, captrivia.PlayerStats{CorrectQuestions: 4, AnsweredQuestions: 5, TotalQuestions: 8, TimeMilliseconds: 7000}, total)
+
+	empty := captrivia.PlayerStats{}
+	assert.Equal(t, float64(0), empty.Accuracy())
+	assert.Equal(t, int64(0), empty.AverageMilliseconds())
+}
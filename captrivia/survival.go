@@ -0,0 +1,165 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type GameMode string
+
+const (
+	GameModeClassic  GameMode = "classic"  // every question is played and the highest score wins
+	GameModeSurvival GameMode = "survival" // players lose a life for every question they miss, the last player standing wins
+	GameModePractice GameMode = "practice" // one player works through the questions at their own pace
+)
+
+const (
+	DefaultLives = 3
+	MaxLives     = 10
+)
+
+func validateMode(mode GameMode, lives int) error {
+	switch mode {
+	case GameModeClassic, GameModeSurvival, "":
+	case GameModePractice:
+		return errors.New("practice games can not be chosen in the game settings")
+	default:
+		return fmt.Errorf("unknown game mode %q", mode)
+	}
+	if lives < 0 || lives > MaxLives {
+		return fmt.Errorf("lives must be between 1 and %d, got %d", MaxLives, lives)
+	}
+	return nil
+}
+
+// setMode changes how the game is played. Survival games without a number of
+// lives start every player with DefaultLives.
+func (g *Game) setMode(mode GameMode, lives int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if mode == "" {
+		mode = GameModeClassic
+	}
+	if mode == GameModeSurvival && lives == 0 {
+		lives = DefaultLives
+	}
+	g.Mode = mode
+	g.Lives = lives
+}
+
+// LivesLeft returns the lives every seated player has left in a survival
+// game. Eliminated players are included with no lives left.
+func (g *Game) LivesLeft() map[string]int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Mode != GameModeSurvival {
+		return nil
+	}
+	lives := make(map[string]int, len(g.PlayersReady))
+	for player := range g.PlayersReady {
+		lives[player] = max(g.Lives-g.livesLost[player], 0)
+	}
+	for player := range g.livesLost {
+		if g.isEliminated(player) {
+			lives[player] = 0
+		}
+	}
+	return lives
+}
+
+// IsEliminated reports whether the player has run out of lives.
+func (g *Game) IsEliminated(player string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.isEliminated(player)
+}
+
+func (g *Game) isEliminated(player string) bool {
+	return g.Mode == GameModeSurvival && g.livesLost[player] >= g.Lives
+}
+
+// Survivors returns the seated players of a survival game that are still in
+// it, in the order they joined.
+func (g *Game) Survivors() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Mode != GameModeSurvival {
+		return nil
+	}
+	return g.survivors()
+}
+
+func (g *Game) survivors() []string {
+	survivors := []string{}
+	for _, player := range g.joinOrder {
+		if !g.isEliminated(player) {
+			survivors = append(survivors, player)
+		}
+	}
+	return survivors
+}
+
+// TakeLives takes a life from every surviving player that didn't answer the
+// current question correctly, whether they answered wrong or not at all. The
+// players that lost a life and those it eliminated are returned. Eliminated
+// players give up their seat and watch the rest of the game as spectators,
+// keeping their score. If the host is eliminated the host passes to the
+// longest seated survivor. Only survival games are affected.
+func (g *Game) TakeLives() (lost []string, eliminated []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Mode != GameModeSurvival {
+		return nil, nil
+	}
+
+	for _, player := range g.survivors() {
+		if g.correctPlayers[player] {
+			continue
+		}
+		g.livesLost[player]++
+		lost = append(lost, player)
+		if g.isEliminated(player) {
+			eliminated = append(eliminated, player)
+			g.eliminate(player)
+		}
+	}
+	return lost, eliminated
+}
+
+// eliminate moves the player from their seat to the spectators. g.mu must be
+// held.
+func (g *Game) eliminate(player string) {
+	delete(g.PlayersReady, player)
+	g.spectators[player] = true
+	g.PlayerCount--
+	g.joinOrder = slices.DeleteFunc(g.joinOrder, func(p string) bool { return p == player })
+
+	if g.Host == player && len(g.joinOrder) > 0 {
+		g.Host = g.joinOrder[0]
+	}
+}
+
+// survivalDecided reports whether a survival game is over before its last
+// question, because one player is left standing or, in a game played alone,
+// the player is out of lives. g.mu must be held.
+func (g *Game) survivalDecided() bool {
+	if g.Mode != GameModeSurvival {
+		return false
+	}
+	left := len(g.survivors())
+	eliminated := 0
+	for player := range g.livesLost {
+		if g.isEliminated(player) {
+			eliminated++
+		}
+	}
+	return left == 0 || (left == 1 && eliminated > 0)
+}
+
+// waitsForEveryone reports whether questions stay open until every player
+// still in the game has answered, rather than closing on a correct answer.
+// g.mu must be held.
+func (g *Game) waitsForEveryone() bool {
+	return g.Mode == GameModeSurvival || (g.TeamScoring == TeamScoringVote && len(g.Teams) > 0)
+}
@@ -0,0 +1,82 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurvival(t *testing.T) {
+	g := CreateTestGame()
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{Mode: captrivia.GameModeSurvival}))
+	assert.Equal(t, captrivia.DefaultLives, g.Lives)
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{Mode: captrivia.GameModeSurvival, Lives: 2}))
+	g.AddPlayer("a")
+	g.AddPlayer("b")
+	g.AddPlayer("c")
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	// the question stays open for everyone still in the game to answer
+	_, outcome := g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.False(t, outcome.CloseQuestion)
+	g.AnswerQuestion("b", q.ID, wrongIndex, now, 5*time.Second)
+
+	// wrong answers and no answer both cost a life
+	lost, eliminated := g.TakeLives()
+	assert.Equal(t, []string{"b", "c"}, lost)
+	assert.Empty(t, eliminated)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "c": 1}, g.LivesLeft())
+	g.GoToNextQuestion()
+
+	q = g.CurrentQuestion()
+	g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	_, outcome = g.AnswerQuestion("b", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.False(t, outcome.CloseQuestion)
+	lost, eliminated = g.TakeLives()
+	assert.Equal(t, []string{"c"}, lost)
+	assert.Equal(t, []string{"c"}, eliminated)
+	assert.True(t, g.IsEliminated("c"))
+	assert.Equal(t, []string{"a", "b"}, g.Survivors())
+
+	// eliminated players give up their seat to watch, their score is kept
+	assert.False(t, g.HasPlayer("c"))
+	assert.True(t, g.IsSpectator("c"))
+	assert.Equal(t, 2, g.PlayerCount)
+	assert.Contains(t, g.Scores, "c")
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "c": 0}, g.LivesLeft())
+	g.GoToNextQuestion()
+
+	// eliminated players can only watch, and aren't waited for
+	for i := 0; i < 2; i++ {
+		q = g.CurrentQuestion()
+		_, outcome = g.AnswerQuestion("c", q.ID, q.CorrectIndex, now, 5*time.Second)
+		assert.Equal(t, captrivia.AnswerRejectedEliminated, outcome.Rejection)
+		g.AnswerQuestion("a", q.ID, (q.CorrectIndex+1)%len(q.Options), now, 5*time.Second)
+		_, outcome = g.AnswerQuestion("b", q.ID, q.CorrectIndex, now, 5*time.Second)
+		assert.True(t, outcome.CloseQuestion)
+		g.TakeLives()
+		if i == 0 {
+			g.GoToNextQuestion()
+		}
+	}
+
+	// the game ends as soon as one player is left standing
+	assert.Equal(t, []string{"b"}, g.Survivors())
+	g.GoToNextQuestion()
+	select {
+	case <-g.GameEndedChan():
+	default:
+		t.Fatal("survival game did not end with one player left")
+	}
+
+	restored, err := captrivia.RestoreGame(g.Snapshot())
+	assert.NoError(t, err)
+	assert.Equal(t, g.LivesLeft(), restored.LivesLeft())
+}
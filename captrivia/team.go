@@ -0,0 +1,224 @@
+package captrivia
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+type TeamScoring string
+
+const (
+	TeamScoringFirstCorrect TeamScoring = "first_correct" // a team scores the points of its first correct answer to each question
+	TeamScoringVote         TeamScoring = "vote"          // a team scores a point when the option most of its players picked is correct
+)
+
+const (
+	MaxTeams          = 8
+	MaxTeamNameLength = 32
+)
+
+var ErrUnknownTeam = errors.New("team is not in the game")
+
+type TeamScore struct {
+	Name    string   `json:"name"`
+	Score   int      `json:"score"`
+	Players []string `json:"players"`
+}
+
+// validateTeams checks the teams and team scoring chosen for a game. Games
+// without teams are played by every player for themselves.
+func validateTeams(teams []string, scoring TeamScoring) error {
+	switch scoring {
+	case TeamScoringFirstCorrect, TeamScoringVote, "":
+	default:
+		return fmt.Errorf("unknown team scoring %q", scoring)
+	}
+	if len(teams) == 0 {
+		return nil
+	}
+	if len(teams) < 2 || len(teams) > MaxTeams {
+		return fmt.Errorf("a team game must have between 2 and %d teams, got %d", MaxTeams, len(teams))
+	}
+	for i, team := range teams {
+		if team == "" || len(team) > MaxTeamNameLength {
+			return fmt.Errorf("team names must be between 1 and %d characters", MaxTeamNameLength)
+		}
+		if slices.Contains(teams[:i], team) {
+			return fmt.Errorf("team %q is listed more than once", team)
+		}
+	}
+	return nil
+}
+
+// setTeams changes the game's teams, players on a team that is no longer in
+// the game have to pick again.
+func (g *Game) setTeams(teams []string, scoring TeamScoring) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(teams) > 0 && scoring == "" {
+		scoring = TeamScoringFirstCorrect
+	}
+	g.Teams = teams
+	g.TeamScoring = scoring
+	for player, team := range g.playerTeams {
+		if !slices.Contains(teams, team) {
+			delete(g.playerTeams, player)
+		}
+	}
+}
+
+// HasTeams reports whether the game is played in teams.
+func (g *Game) HasTeams() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.Teams) > 0
+}
+
+// PickTeam puts a seated player on one of the game's teams.
+func (g *Game) PickTeam(player string, team string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.State != GameStateWaiting {
+		return ErrGameStarted
+	}
+	if _, ok := g.PlayersReady[player]; !ok {
+		return fmt.Errorf("%w: %s", ErrPlayerNotInGame, player)
+	}
+	if !slices.Contains(g.Teams, team) {
+		return fmt.Errorf("%w: %s", ErrUnknownTeam, team)
+	}
+	g.playerTeams[player] = team
+	return nil
+}
+
+// PlayerTeams returns the team of every player that is on one.
+func (g *Game) PlayerTeams() map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return maps.Clone(g.playerTeams)
+}
+
+// BalanceTeams puts every seated player that hasn't picked a team on the team
+// with the fewest players, in the order they joined the game.
+func (g *Game) BalanceTeams() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.Teams) == 0 {
+		return
+	}
+
+	sizes := make(map[string]int, len(g.Teams))
+	for _, team := range g.playerTeams {
+		sizes[team]++
+	}
+	for _, player := range g.joinOrder {
+		if _, ok := g.playerTeams[player]; ok {
+			continue
+		}
+		smallest := g.Teams[0]
+		for _, team := range g.Teams[1:] {
+			if sizes[team] < sizes[smallest] {
+				smallest = team
+			}
+		}
+		g.playerTeams[player] = smallest
+		sizes[smallest]++
+	}
+}
+
+// TeamScores returns the standings of the game's teams, highest score first.
+func (g *Game) TeamScores() []TeamScore {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	scores := make([]TeamScore, 0, len(g.Teams))
+	for _, team := range g.Teams {
+		players := []string{}
+		for _, player := range g.joinOrder {
+			if g.playerTeams[player] == team {
+				players = append(players, player)
+			}
+		}
+		scores = append(scores, TeamScore{Name: team, Score: g.teamScores[team], Players: players})
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
+
+// scoreTeamAnswer adds an accepted answer to its player's team total. In
+// first correct team scoring a team scores the first correct answer from any
+// of its players, the votes of vote scoring teams are counted once the
+// question is over. g.mu must be held.
+func (g *Game) scoreTeamAnswer(player string, correct bool, points int) {
+	team, ok := g.playerTeams[player]
+	if !ok || g.TeamScoring == TeamScoringVote || !correct || g.teamsScored[team] {
+		return
+	}
+	g.teamsScored[team] = true
+	g.teamScores[team] += points
+}
+
+// teamsClosed reports whether every team with players has scored the current
+// question, which ends it in first correct team scoring. g.mu must be held.
+func (g *Game) teamsClosed() bool {
+	if g.TeamScoring != TeamScoringFirstCorrect {
+		return false
+	}
+	for _, team := range g.playerTeams {
+		if !g.teamsScored[team] {
+			return false
+		}
+	}
+	return len(g.teamsScored) > 0
+}
+
+// scoreTeamVotes awards a point to every vote scoring team whose most picked
+// option for the current question is the correct one. Tied votes go to
+// neither option. g.mu must be held.
+func (g *Game) scoreTeamVotes() {
+	if len(g.Teams) == 0 || g.TeamScoring != TeamScoringVote {
+		return
+	}
+	q := g.questions[g.currentQuestionIndex]
+
+	// a player that answered more than once votes with their last answer
+	picks := make(map[string]int)
+	for _, a := range g.answers {
+		if a.Accepted && a.QuestionID == q.ID {
+			picks[a.Player] = a.Index
+		}
+	}
+
+	votes := make(map[string]map[int]int)
+	for player, index := range picks {
+		team, ok := g.playerTeams[player]
+		if !ok {
+			continue
+		}
+		if votes[team] == nil {
+			votes[team] = make(map[int]int)
+		}
+		votes[team][index]++
+	}
+
+	for team, picks := range votes {
+		best, bestCount, tied := 0, 0, false
+		for index, count := range picks {
+			switch {
+			case count > bestCount:
+				best, bestCount, tied = index, count, false
+			case count == bestCount:
+				tied = true
+			}
+		}
+		if !tied && best == q.CorrectIndex {
+			g.teamScores[team]++
+		}
+	}
+}
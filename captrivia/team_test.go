@@ -0,0 +1,157 @@
+package captrivia_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTeams(t *testing.T) {
+	settings := func(teams []string, scoring captrivia.TeamScoring) captrivia.GameSettings {
+		return captrivia.GameSettings{QuestionCount: 5, Teams: teams, TeamScoring: scoring}
+	}
+
+	assert.NoError(t, settings(nil, "").Validate())
+	assert.NoError(t, settings([]string{"red", "blue"}, captrivia.TeamScoringVote).Validate())
+	assert.Error(t, settings([]string{"red"}, "").Validate())
+	assert.Error(t, settings([]string{"red", "red"}, "").Validate())
+	assert.Error(t, settings([]string{"red", ""}, "").Validate())
+	assert.Error(t, settings([]string{"red", "blue"}, "loudest").Validate())
+}
+
+func newTeamGame(t *testing.T, scoring captrivia.TeamScoring, players ...string) *captrivia.Game {
+	g := CreateTestGame()
+	assert.NoError(t, g.ApplySettings(captrivia.GameSettings{
+		ScoringMode: captrivia.ScoringModeAllCorrect,
+		Teams:       []string{"red", "blue"},
+		TeamScoring: scoring,
+	}))
+	for _, player := range players {
+		g.AddPlayer(player)
+	}
+	return &g
+}
+
+func TestPickAndBalanceTeams(t *testing.T) {
+	g := newTeamGame(t, "", "a", "b", "c", "d")
+	assert.Equal(t, captrivia.TeamScoringFirstCorrect, g.TeamScoring)
+
+	assert.ErrorIs(t, g.PickTeam("a", "green"), captrivia.ErrUnknownTeam)
+	assert.ErrorIs(t, g.PickTeam("nobody", "red"), captrivia.ErrPlayerNotInGame)
+	assert.NoError(t, g.PickTeam("a", "red"))
+	assert.NoError(t, g.PickTeam("b", "red"))
+
+	g.BalanceTeams()
+	assert.Equal(t, map[string]string{"a": "red", "b": "red", "c": "blue", "d": "blue"}, g.PlayerTeams())
+
+	g.State = captrivia.GameStateCountdown
+	assert.ErrorIs(t, g.PickTeam("a", "blue"), captrivia.ErrGameStarted)
+}
+
+func TestTeamScoringFirstCorrect(t *testing.T) {
+	g := newTeamGame(t, captrivia.TeamScoringFirstCorrect, "a", "b", "c")
+	g.PickTeam("a", "red")
+	g.PickTeam("b", "red")
+	g.PickTeam("c", "blue")
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	now := time.Now()
+	g.QuestionDisplayed(now)
+	g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	g.AnswerQuestion("b", q.ID, q.CorrectIndex, now, 5*time.Second)
+
+	// only the first correct answer of a team counts for it
+	teams := g.TeamScores()
+	assert.Equal(t, captrivia.TeamScore{Name: "red", Score: 1, Players: []string{"a", "b"}}, teams[0])
+	assert.Equal(t, captrivia.TeamScore{Name: "blue", Score: 0, Players: []string{"c"}}, teams[1])
+	assert.Equal(t, 1, g.Scores["a"])
+	assert.Equal(t, 1, g.Scores["b"])
+}
+
+func TestTeamScoringWithFirstCorrectPlayers(t *testing.T) {
+	g := newTeamGame(t, captrivia.TeamScoringFirstCorrect, "a", "b", "c")
+	assert.NoError(t, g.SetScoringMode(captrivia.ScoringModeFirstCorrect))
+	g.PickTeam("a", "red")
+	g.PickTeam("b", "red")
+	g.PickTeam("c", "blue")
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	// the question stays open for blue after red answers it
+	_, outcome := g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.False(t, outcome.CloseQuestion)
+	_, outcome = g.AnswerQuestion("c", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.True(t, outcome.Accepted)
+	assert.True(t, outcome.CloseQuestion)
+
+	for _, team := range g.TeamScores() {
+		assert.Equal(t, 1, team.Score, team.Name)
+	}
+}
+
+func TestTeamScoringVote(t *testing.T) {
+	g := newTeamGame(t, captrivia.TeamScoringVote, "a", "b", "c", "d", "e")
+	for player, team := range map[string]string{"a": "red", "b": "red", "c": "red", "d": "blue", "e": "blue"} {
+		g.PickTeam(player, team)
+	}
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	// red picks the right answer two to one, blue is split
+	g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	g.AnswerQuestion("b", q.ID, wrongIndex, now, 5*time.Second)
+	_, outcome := g.AnswerQuestion("d", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.False(t, outcome.CloseQuestion)
+	g.AnswerQuestion("e", q.ID, wrongIndex, now, 5*time.Second)
+	_, outcome = g.AnswerQuestion("c", q.ID, q.CorrectIndex, now, 5*time.Second)
+	assert.True(t, outcome.CloseQuestion)
+
+	g.GoToNextQuestion()
+	teams := g.TeamScores()
+	assert.Equal(t, "red", teams[0].Name)
+	assert.Equal(t, 1, teams[0].Score)
+	assert.Equal(t, 0, teams[1].Score)
+
+	// team scores are kept through a restart
+	restored, err := captrivia.RestoreGame(g.Snapshot())
+	assert.NoError(t, err)
+	assert.Equal(t, teams, restored.TeamScores())
+}
+
+func TestTeamScoringVoteLastAnswer(t *testing.T) {
+	g := newTeamGame(t, captrivia.TeamScoringVote, "a", "b", "c")
+	g.AnswersPerQuestion = 3
+	for player, team := range map[string]string{"a": "red", "b": "red", "c": "red"} {
+		g.PickTeam(player, team)
+	}
+	g.State = captrivia.GameStateQuestion
+
+	q := g.CurrentQuestion()
+	wrongIndex := (q.CorrectIndex + 1) % len(q.Options)
+	now := time.Now()
+	g.QuestionDisplayed(now)
+
+	// a changes their mind twice, only their last answer is their vote so
+	// red is two to one for the right answer
+	g.AnswerQuestion("a", q.ID, wrongIndex, now, 5*time.Second)
+	g.AnswerQuestion("a", q.ID, wrongIndex, now, 5*time.Second)
+	g.AnswerQuestion("a", q.ID, q.CorrectIndex, now, 5*time.Second)
+	g.AnswerQuestion("b", q.ID, wrongIndex, now, 5*time.Second)
+	g.AnswerQuestion("c", q.ID, q.CorrectIndex, now, 5*time.Second)
+
+	g.GoToNextQuestion()
+	teams := g.TeamScores()
+	assert.Equal(t, "red", teams[0].Name)
+	assert.Equal(t, 1, teams[0].Score)
+}
@@ -3,11 +3,16 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/dylanconnolly/captrivia-be/redis"
 	"github.com/dylanconnolly/captrivia-be/server"
 )
@@ -17,6 +22,10 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate-questions" {
+		os.Exit(validateQuestions(os.Args[2:], os.Stdout))
+	}
+
 	cfg := NewConfig()
 
 	flag.StringVar(&listen, "listen", ":8080", "Listen address")
@@ -24,12 +33,24 @@ func main() {
 
 	ctx, _ := context.WithCancel(context.Background())
 
-	app := NewApp(cfg)
+	app, err := NewApp(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	go app.hub.Run(ctx)
 
+	if cfg.QuestionsReload > 0 {
+		go app.reloader.Watch(ctx, time.Duration(cfg.QuestionsReload)*time.Second)
+	}
+	go reloadOnHangup(app.reloader)
+
+	if err := app.hub.RestoreGames(ctx); err != nil {
+		log.Printf("failed to restore games: %s", err)
+	}
+
 	log.Println("listening on ", app.httpServer.Addr)
-	err := app.httpServer.ListenAndServe()
+	err = app.httpServer.ListenAndServe()
 	if err != nil {
 		log.Fatalf("failed to listen: %s", err)
 	}
@@ -39,17 +60,67 @@ type App struct {
 	hub        *server.Hub
 	gameServer *server.GameServer
 	httpServer *http.Server
+	reloader   *captrivia.QuestionReloader
 }
 
-func NewApp(cfg Config) *App {
-	hub := server.NewHub(redis.NewGameService(cfg.RedisAddr, cfg.RedisTTL), cfg.CountdownDuration, cfg.QuestionDuration)
+func NewApp(cfg Config) (*App, error) {
+	// questions live in Redis so changes made through the admin API or by
+	// reloading the questions file reach new games without a restart
+	questionService := redis.NewQuestionService(cfg.RedisAddr)
+	reloader := captrivia.NewQuestionReloader(cfg.QuestionsPath, questionService)
+	if err := loadQuestions(reloader, questionService); err != nil {
+		return nil, err
+	}
+	bank := captrivia.NewServiceQuestionBank(questionService)
+
+	hub := server.NewHub(redis.NewGameService(cfg.RedisAddr, cfg.RedisTTL), bank, cfg.CountdownDuration, cfg.QuestionDuration)
+	hub.ReconnectGrace = time.Duration(cfg.ReconnectGrace) * time.Second
+	hub.RevealSec = cfg.RevealDuration
+	hub.NodeID = cfg.NodeID
+	if cfg.Bus == "redis" {
+		hub.Bus = redis.NewBus(cfg.RedisAddr)
+	}
 	gameServer := server.NewGameServer(hub)
-	httpServer := server.NewHTTPServer(listen, gameServer)
+	var adminServer *server.AdminServer
+	if cfg.AdminToken != "" {
+		adminServer = server.NewAdminServer(questionService, reloader, cfg.AdminToken)
+	}
+	httpServer := server.NewHTTPServer(listen, gameServer, adminServer)
 
 	return &App{
 		hub:        hub,
 		gameServer: gameServer,
 		httpServer: httpServer,
+		reloader:   reloader,
+	}, nil
+}
+
+// loadQuestions loads the questions file on startup. A bad file is only fatal
+// when there are no stored questions to fall back on.
+func loadQuestions(reloader *captrivia.QuestionReloader, qs captrivia.QuestionService) error {
+	_, err := reloader.Reload()
+	if err == nil {
+		return nil
+	}
+
+	existing, getErr := qs.GetQuestions()
+	if getErr != nil || len(existing) == 0 {
+		return fmt.Errorf("error loading questions: %w", err)
+	}
+	log.Printf("error loading questions, using the %d stored questions: %s", len(existing), err)
+	return nil
+}
+
+// reloadOnHangup reloads the questions file whenever the process receives a
+// SIGHUP.
+func reloadOnHangup(reloader *captrivia.QuestionReloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		if _, err := reloader.Reload(); err != nil {
+			log.Printf("error reloading questions: %s", err)
+		}
 	}
 }
 
@@ -58,6 +129,13 @@ type Config struct {
 	RedisTTL          int
 	CountdownDuration int
 	QuestionDuration  int
+	RevealDuration    int
+	ReconnectGrace    int
+	Bus               string
+	NodeID            string
+	QuestionsPath     string
+	AdminToken        string
+	QuestionsReload   int
 }
 
 func NewConfig() Config {
@@ -77,6 +155,35 @@ func NewConfig() Config {
 	if qd == "" {
 		qd = "5"
 	}
+	rd := os.Getenv("REVEAL_DURATION_SEC")
+	if rd == "" {
+		rd = "3"
+	}
+	rg := os.Getenv("RECONNECT_GRACE_SEC")
+	if rg == "" {
+		rg = "30"
+	}
+	bus := os.Getenv("BROADCAST_BUS")
+	if bus == "" {
+		bus = "memory"
+	}
+	if bus != "memory" && bus != "redis" {
+		log.Fatal("BROADCAST_BUS env variable must be one of memory or redis")
+	}
+	nodeID := os.Getenv("NODE_ID")
+	if nodeID == "" {
+		// the hostname is stable across restarts of the same container so the
+		// node resumes the games it was hosting
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatal("NODE_ID env variable not found and hostname could not be read ", err)
+		}
+		nodeID = hostname
+	}
+	reload := os.Getenv("QUESTIONS_RELOAD_SEC")
+	if reload == "" {
+		reload = "30"
+	}
 	questions_path := os.Getenv("QUESTIONS_FILE_PATH")
 	if questions_path == "" {
 		log.Fatal("QUESTIONS_FILE_PATH env variable not found. Please provide full path to questions.json")
@@ -94,12 +201,31 @@ func NewConfig() Config {
 	if err != nil {
 		log.Fatal("error converting env variable QUESTION_DURATION_SEC to integer ", err)
 	}
+	rdInt, err := strconv.Atoi(rd)
+	if err != nil {
+		log.Fatal("error converting env variable REVEAL_DURATION_SEC to integer ", err)
+	}
+	rgInt, err := strconv.Atoi(rg)
+	if err != nil {
+		log.Fatal("error converting env variable RECONNECT_GRACE_SEC to integer ", err)
+	}
+	reloadInt, err := strconv.Atoi(reload)
+	if err != nil {
+		log.Fatal("error converting env variable QUESTIONS_RELOAD_SEC to integer ", err)
+	}
 
 	cfg := Config{
 		RedisAddr:         addr,
 		RedisTTL:          ttlInt,
 		CountdownDuration: cdInt,
 		QuestionDuration:  qdInt,
+		RevealDuration:    rdInt,
+		ReconnectGrace:    rgInt,
+		Bus:               bus,
+		NodeID:            nodeID,
+		QuestionsPath:     questions_path,
+		AdminToken:        os.Getenv("ADMIN_TOKEN"), // the admin API is disabled when unset
+		QuestionsReload:   reloadInt,                // polling is disabled when 0
 	}
 
 	return cfg
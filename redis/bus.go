@@ -0,0 +1,54 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Bus publishes broadcasts through Redis Pub/Sub so they reach every server
+// instance connected to the same Redis.
+type Bus struct {
+	rdb *redis.Client
+}
+
+func NewBus(addr string) *Bus {
+	return &Bus{rdb: NewClient(addr)}
+}
+
+func (b *Bus) Publish(topic string, message []byte) error {
+	return b.rdb.Publish(ctx, topic, message).Err()
+}
+
+// Subscribe returns a channel receiving every message published on the topic
+// until subCtx is done, at which point the channel is closed.
+func (b *Bus) Subscribe(subCtx context.Context, topic string) (<-chan []byte, error) {
+	pubsub := b.rdb.Subscribe(subCtx, topic)
+	// wait for the subscription to be confirmed so no publishes are missed
+	if _, err := pubsub.Receive(subCtx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("error subscribing to %s: %w", topic, err)
+	}
+
+	messages := make(chan []byte, 256)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				messages <- []byte(msg.Payload)
+			case <-subCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/redis/go-redis/v9"
+)
+
+// daily challenge results are kept long enough for players to look back on
+// past challenges
+const dailyResultsTTL = 90 * 24 * time.Hour
+
+// SaveDailyResult records a player's result in the challenge of its date and
+// ranks them in the day's leaderboard. Only a player's first result for a date
+// is kept.
+func (s *GameService) SaveDailyResult(result captrivia.DailyResult) error {
+	resultsKey := fmt.Sprintf(dailyResultsKey, result.Date)
+	leaderboardKey := fmt.Sprintf(dailyLeaderboardKey, result.Date)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	saved, err := s.rdb.HSetNX(ctx, resultsKey, result.PlayerName, b).Result()
+	if err != nil {
+		return fmt.Errorf("error saving daily result for player %s: %w", result.PlayerName, err)
+	}
+	if !saved {
+		return nil
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, leaderboardKey, redis.Z{Score: result.RankScore(), Member: result.PlayerName})
+		pipe.Expire(ctx, resultsKey, dailyResultsTTL)
+		pipe.Expire(ctx, leaderboardKey, dailyResultsTTL)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error ranking daily result for player %s: %w", result.PlayerName, err)
+	}
+	return nil
+}
+
+// ReserveDaily records that the player has started the challenge of the date,
+// returning false if they had already started it. Reserving is a single SADD
+// so two games can't both start the challenge for a player.
+func (s *GameService) ReserveDaily(date string, player string) (bool, error) {
+	key := fmt.Sprintf(dailyPlayersKey, date)
+
+	var added *redis.IntCmd
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, key, player)
+		pipe.Expire(ctx, key, dailyResultsTTL)
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error reserving daily challenge for player %s: %w", player, err)
+	}
+	return added.Val() == 1, nil
+}
+
+// PinDailyQuestions keeps the ids as the questions of the date's challenge
+// unless another node pinned some first, and returns the ids that are kept.
+func (s *GameService) PinDailyQuestions(date string, ids []string) ([]string, error) {
+	key := fmt.Sprintf(dailyQuestionsKey, date)
+
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	pinned, err := s.rdb.SetNX(ctx, key, b, dailyResultsTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error pinning daily questions for %s: %w", date, err)
+	}
+	if pinned {
+		return ids, nil
+	}
+
+	b, err = s.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting daily questions for %s: %w", date, err)
+	}
+	var kept []string
+	if err := json.Unmarshal(b, &kept); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// GetDailyQuestions returns the ids pinned as the questions of the date's
+// challenge, false if none have been pinned yet.
+func (s *GameService) GetDailyQuestions(date string) ([]string, bool, error) {
+	b, err := s.rdb.Get(ctx, fmt.Sprintf(dailyQuestionsKey, date)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting daily questions for %s: %w", date, err)
+	}
+	var ids []string
+	if err := json.Unmarshal(b, &ids); err != nil {
+		return nil, false, err
+	}
+	return ids, true, nil
+}
+
+// GetDailyLeaderboard returns a page of the results in the challenge of the
+// date, best first.
+func (s *GameService) GetDailyLeaderboard(date string, offset int, limit int) ([]captrivia.DailyResult, error) {
+	names, err := s.rdb.ZRevRange(ctx, fmt.Sprintf(dailyLeaderboardKey, date), int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return []captrivia.DailyResult{}, nil
+	}
+
+	resp, err := s.rdb.HMGet(ctx, fmt.Sprintf(dailyResultsKey, date), names...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]captrivia.DailyResult, 0, len(names))
+	for i, v := range resp {
+		b, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var result captrivia.DailyResult
+		if err := json.Unmarshal([]byte(b), &result); err != nil {
+			return nil, err
+		}
+		result.Rank = offset + i + 1
+		leaderboard = append(leaderboard, result)
+	}
+	return leaderboard, nil
+}
@@ -1,9 +1,12 @@
 package redis
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dylanconnolly/captrivia-be/captrivia"
@@ -11,6 +14,10 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// answer logs are kept long after the game itself expires so disputes can be
+// resolved
+const answerLogTTL = 7 * 24 * time.Hour
+
 type GameService struct {
 	rdb               *redis.Client
 	DBAddr            string
@@ -44,19 +51,83 @@ func redisHashToGame(redisHash map[string]string) (captrivia.RepositoryGame, err
 		return captrivia.RepositoryGame{}, err
 	}
 
+	// settings are missing from games saved before they were added
+	questionSeconds, _ := strconv.Atoi(redisHash["question_seconds"])
+	countdownSeconds, _ := strconv.Atoi(redisHash["countdown_seconds"])
+	revealSeconds, _ := strconv.Atoi(redisHash["reveal_seconds"])
+	maxPlayers, _ := strconv.Atoi(redisHash["max_players"])
+	private, _ := strconv.ParseBool(redisHash["private"])
+	hasPassword, _ := strconv.ParseBool(redisHash["has_password"])
+
+	var categories []string
+	if c := redisHash["categories"]; c != "" {
+		categories = strings.Split(c, ",")
+	}
+
 	return captrivia.RepositoryGame{
-		ID:            id,
-		Name:          redisHash["name"],
-		PlayerCount:   playerCount,
-		QuestionCount: questionCount,
-		State:         captrivia.GameState(redisHash["state"]),
+		ID:               id,
+		Name:             redisHash["name"],
+		PlayerCount:      playerCount,
+		QuestionCount:    questionCount,
+		Mode:             captrivia.GameMode(redisHash["mode"]),
+		QuestionSeconds:  questionSeconds,
+		CountdownSeconds: countdownSeconds,
+		RevealSeconds:    revealSeconds,
+		MaxPlayers:       maxPlayers,
+		ScoringMode:      captrivia.ScoringMode(redisHash["scoring_mode"]),
+		Categories:       categories,
+		Private:          private,
+		HasPassword:      hasPassword,
+		State:            captrivia.GameState(redisHash["state"]),
 	}, nil
 }
 
+// SaveGame stores the game's lobby listing as well as a full snapshot of its
+// state which is used to resume the game if the server restarts.
 func (s *GameService) SaveGame(game *captrivia.Game) error {
 	key := fmt.Sprintf(gameKey, game.ID)
 	repGame := game.ToRepositoryGame()
-	return s.rdb.HSet(ctx, key, repGame.ToHash()).Err()
+
+	snapshot, err := json.Marshal(game.Snapshot())
+	if err != nil {
+		return fmt.Errorf("error marshalling snapshot for gameID=%s: %w", game.ID, err)
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, repGame.ToHash())
+		pipe.Set(ctx, fmt.Sprintf(snapshotKey, game.ID), snapshot, redis.KeepTTL)
+		return nil
+	})
+	return err
+}
+
+// GetGameSnapshots returns the snapshot of every game that has not expired.
+func (s *GameService) GetGameSnapshots() ([]captrivia.GameSnapshot, error) {
+	var snapshots []captrivia.GameSnapshot
+
+	iter := s.rdb.Scan(ctx, 0, fmt.Sprintf(snapshotKey, "*"), 0).Iterator()
+	for iter.Next(ctx) {
+		b, err := s.rdb.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// expired between the scan and the get
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var snapshot captrivia.GameSnapshot
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			log.Printf("error unmarshalling game snapshot %s: %s", iter.Val(), err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
 }
 
 func (s *GameService) GetGames() ([]captrivia.RepositoryGame, error) {
@@ -94,6 +165,93 @@ func (s *GameService) GetGames() ([]captrivia.RepositoryGame, error) {
 }
 
 func (s *GameService) ExpireGame(gameID uuid.UUID) error {
-	key := fmt.Sprintf(gameKey, gameID)
-	return s.rdb.Expire(ctx, key, s.GameTTL).Err()
+	code, err := s.rdb.Get(ctx, fmt.Sprintf(gameInviteKey, gameID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, fmt.Sprintf(gameKey, gameID), s.GameTTL)
+		pipe.Expire(ctx, fmt.Sprintf(snapshotKey, gameID), s.GameTTL)
+		pipe.Expire(ctx, fmt.Sprintf(ownerKey, gameID), s.GameTTL)
+		if code != "" {
+			pipe.Expire(ctx, fmt.Sprintf(gameInviteKey, gameID), s.GameTTL)
+			pipe.Expire(ctx, fmt.Sprintf(inviteKey, code), s.GameTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// ReserveInviteCode maps an invite code to a private game, returning false if
+// the code is already in use by another game.
+func (s *GameService) ReserveInviteCode(code string, gameID uuid.UUID) (bool, error) {
+	ok, err := s.rdb.SetNX(ctx, fmt.Sprintf(inviteKey, code), gameID.String(), 0).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := s.rdb.Set(ctx, fmt.Sprintf(gameInviteKey, gameID), code, 0).Err(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetInviteCodeGame returns the game an invite code is for, or uuid.Nil if
+// there is no game with the code.
+func (s *GameService) GetInviteCodeGame(code string) (uuid.UUID, error) {
+	id, err := s.rdb.Get(ctx, fmt.Sprintf(inviteKey, code)).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(id)
+}
+
+// SetGameOwner records the server instance hosting a game so commands for
+// the game can be routed to it.
+func (s *GameService) SetGameOwner(gameID uuid.UUID, node string) error {
+	return s.rdb.Set(ctx, fmt.Sprintf(ownerKey, gameID), node, 0).Err()
+}
+
+// GetGameOwner returns the server instance hosting a game, or an empty string
+// if no owner is recorded.
+func (s *GameService) GetGameOwner(gameID uuid.UUID) (string, error) {
+	owner, err := s.rdb.Get(ctx, fmt.Sprintf(ownerKey, gameID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return owner, err
+}
+
+func (s *GameService) SaveAnswerLog(gameID uuid.UUID, answers []captrivia.AnswerRecord) error {
+	key := fmt.Sprintf(answerLogKey, gameID)
+	if answers == nil {
+		answers = []captrivia.AnswerRecord{}
+	}
+	b, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, key, b, answerLogTTL).Err()
+}
+
+// GetAnswerLog returns the answer log of a finished game. A nil slice is
+// returned when no log has been saved for the game.
+func (s *GameService) GetAnswerLog(gameID uuid.UUID) ([]captrivia.AnswerRecord, error) {
+	key := fmt.Sprintf(answerLogKey, gameID)
+	b, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	answers := []captrivia.AnswerRecord{}
+	if err := json.Unmarshal(b, &answers); err != nil {
+		return nil, err
+	}
+	return answers, nil
 }
@@ -0,0 +1,242 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/redis/go-redis/v9"
+)
+
+// QuestionService stores every question as JSON in a single hash keyed by
+// question ID. The version is a counter bumped alongside every change. The IDs
+// of the questions loaded from the questions file are kept in a set, admin
+// changes take a question out of it so that reloading the file leaves it be.
+type QuestionService struct {
+	rdb *redis.Client
+}
+
+func NewQuestionService(dbAddr string) *QuestionService {
+	return &QuestionService{rdb: NewClient(dbAddr)}
+}
+
+func (s *QuestionService) GetQuestions() ([]captrivia.Question, error) {
+	resp, err := s.rdb.HGetAll(ctx, questionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return hashToQuestions(resp)
+}
+
+func (s *QuestionService) GetQuestion(id string) (captrivia.Question, error) {
+	data, err := s.rdb.HGet(ctx, questionsKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return captrivia.Question{}, captrivia.ErrQuestionNotFound
+	}
+	if err != nil {
+		return captrivia.Question{}, err
+	}
+
+	var q captrivia.Question
+	err = json.Unmarshal(data, &q)
+	return q, err
+}
+
+func (s *QuestionService) CreateQuestion(q captrivia.Question) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.rdb.HSetNX(ctx, questionsKey, q.ID, data).Result()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return captrivia.ErrQuestionExists
+	}
+	return s.rdb.Incr(ctx, questionsVersionKey).Err()
+}
+
+func (s *QuestionService) UpdateQuestion(q captrivia.Question) error {
+	exists, err := s.rdb.HExists(ctx, questionsKey, q.ID).Result()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return captrivia.ErrQuestionNotFound
+	}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, questionsKey, q.ID, data)
+		pipe.SRem(ctx, questionsFileKey, q.ID)
+		pipe.Incr(ctx, questionsVersionKey)
+		return nil
+	})
+	return err
+}
+
+// RetireQuestion stops a question being drawn for new games. The question is
+// kept so that answer logs referring to it still make sense.
+func (s *QuestionService) RetireQuestion(id string) error {
+	q, err := s.GetQuestion(id)
+	if err != nil {
+		return err
+	}
+	q.Retired = true
+	return s.UpdateQuestion(q)
+}
+
+// ImportQuestions creates or replaces every question in a single transaction.
+func (s *QuestionService) ImportQuestions(questions []captrivia.Question) error {
+	values, err := questionValues(questions)
+	if err != nil || len(values) == 0 {
+		return err
+	}
+
+	ids := make([]any, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, questionsKey, values)
+		pipe.SRem(ctx, questionsFileKey, ids...)
+		pipe.Incr(ctx, questionsVersionKey)
+		return nil
+	})
+	return err
+}
+
+// MergeQuestions merges the file's questions into the stored ones in a single
+// transaction so that games never draw from a mix of the old and new
+// questions. The transaction is retried if an admin changes the questions
+// while the merge is being worked out.
+func (s *QuestionService) MergeQuestions(source string, questions []captrivia.Question) error {
+	merge := func(tx *redis.Tx) error {
+		hash, err := tx.HGetAll(ctx, questionsKey).Result()
+		if err != nil {
+			return err
+		}
+		stored, err := hashToQuestions(hash)
+		if err != nil {
+			return err
+		}
+		members, err := tx.SMembers(ctx, questionsFileKey).Result()
+		if err != nil {
+			return err
+		}
+		fromFile := make(map[string]bool, len(members))
+		for _, id := range members {
+			fromFile[id] = true
+		}
+
+		merged, fileIDs := captrivia.MergeQuestionsFile(stored, fromFile, questions)
+		values, err := questionValues(merged)
+		if err != nil {
+			return err
+		}
+		ids := make([]any, len(fileIDs))
+		for i, id := range fileIDs {
+			ids[i] = id
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, questionsKey, questionsFileKey)
+			if len(values) > 0 {
+				pipe.HSet(ctx, questionsKey, values)
+			}
+			if len(ids) > 0 {
+				pipe.SAdd(ctx, questionsFileKey, ids...)
+			}
+			pipe.Set(ctx, questionsSourceKey, source, 0)
+			pipe.Incr(ctx, questionsVersionKey)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err := s.rdb.Watch(ctx, merge, questionsKey, questionsFileKey)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("questions kept changing while merging the questions file")
+}
+
+func (s *QuestionService) GetQuestionSet() (string, []captrivia.Question, error) {
+	var version *redis.StringCmd
+	var hash *redis.MapStringStringCmd
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		version = pipe.Get(ctx, questionsVersionKey)
+		hash = pipe.HGetAll(ctx, questionsKey)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", nil, err
+	}
+
+	questions, err := hashToQuestions(hash.Val())
+	if err != nil {
+		return "", nil, err
+	}
+	return versionOrZero(version.Val()), questions, nil
+}
+
+func (s *QuestionService) GetQuestionsVersion() (string, error) {
+	version, err := s.rdb.Get(ctx, questionsVersionKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+	return versionOrZero(version), nil
+}
+
+// GetQuestionsSource returns the hash of the questions file the stored
+// questions were loaded from, or an empty string if they never were.
+func (s *QuestionService) GetQuestionsSource() (string, error) {
+	source, err := s.rdb.Get(ctx, questionsSourceKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return source, err
+}
+
+func questionValues(questions []captrivia.Question) (map[string]any, error) {
+	values := make(map[string]any, len(questions))
+	for _, q := range questions {
+		data, err := json.Marshal(q)
+		if err != nil {
+			return nil, err
+		}
+		values[q.ID] = data
+	}
+	return values, nil
+}
+
+func hashToQuestions(redisHash map[string]string) ([]captrivia.Question, error) {
+	questions := make([]captrivia.Question, 0, len(redisHash))
+	for id, data := range redisHash {
+		var q captrivia.Question
+		if err := json.Unmarshal([]byte(data), &q); err != nil {
+			return nil, fmt.Errorf("error unmarshalling question %s: %w", id, err)
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// the version counter does not exist until the questions first change
+func versionOrZero(version string) string {
+	if version == "" {
+		return "0"
+	}
+	return version
+}
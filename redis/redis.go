@@ -7,13 +7,29 @@ import (
 )
 
 const (
-	gameKey string = "game:%s"
+	gameKey             string = "game:%s"
+	answerLogKey        string = "game:%s:answers"
+	snapshotKey         string = "game:%s:snapshot"
+	ownerKey            string = "game:%s:owner"
+	gameInviteKey       string = "game:%s:invite"
+	inviteKey           string = "invite:%s"
+	playerStatsKey      string = "player:%s:stats"
+	leaderboardKey      string = "leaderboard:%s"
+	dailyResultsKey     string = "daily:%s:results"
+	dailyLeaderboardKey string = "daily:%s:leaderboard"
+	dailyPlayersKey     string = "daily:%s:players"
+	dailyQuestionsKey   string = "daily:%s:questions"
+	questionsKey        string = "questions"
+	questionsVersionKey string = "questions:version"
+	questionsSourceKey  string = "questions:source"
+	questionsFileKey    string = "questions:file"
 )
 
-var ctx = context.Background()
+// maxTxRetries is how many times a WATCHed transaction is retried when the
+// watched keys change underneath it.
+const maxTxRetries = 10
 
-type Question struct {
-}
+var ctx = context.Background()
 
 func NewClient(addr string) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/redis/go-redis/v9"
+)
+
+func redisHashToPlayerStats(name string, redisHash map[string]string) (captrivia.PlayerStats, error) {
+	correct, err := strconv.Atoi(redisHash["correct_questions"])
+	if err != nil {
+		return captrivia.PlayerStats{}, err
+	}
+
+	total, err := strconv.Atoi(redisHash["total_questions"])
+	if err != nil {
+		return captrivia.PlayerStats{}, err
+	}
+
+	// stats saved before answered questions were kept apart only counted
+	// the questions answered
+	answered := total
+	if v, ok := redisHash["answered_questions"]; ok {
+		if answered, err = strconv.Atoi(v); err != nil {
+			return captrivia.PlayerStats{}, err
+		}
+	}
+
+	ms, err := strconv.ParseInt(redisHash["time_milliseconds"], 10, 64)
+	if err != nil {
+		return captrivia.PlayerStats{}, err
+	}
+
+	lastUpdate, err := time.Parse(time.RFC3339, redisHash["last_update"])
+	if err != nil {
+		return captrivia.PlayerStats{}, err
+	}
+
+	return captrivia.PlayerStats{
+		PlayerName:        name,
+		CorrectQuestions:  correct,
+		AnsweredQuestions: answered,
+		TotalQuestions:    total,
+		TimeMilliseconds:  ms,
+		LastUpdate:        lastUpdate,
+	}, nil
+}
+
+// UpdatePlayerStats adds the stats from a finished game on to each player's
+// lifetime stats and re-ranks the player in every leaderboard sorted set. Each
+// player's stats and rankings are updated in one transaction, retried if the
+// stats change underneath it, so concurrent games can't lose an update or
+// leave the leaderboards ranking stale stats.
+func (s *GameService) UpdatePlayerStats(stats []captrivia.PlayerStats) error {
+	for _, ps := range stats {
+		if err := s.updatePlayerStats(ps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GameService) updatePlayerStats(ps captrivia.PlayerStats) error {
+	key := fmt.Sprintf(playerStatsKey, ps.PlayerName)
+
+	update := func(tx *redis.Tx) error {
+		resp, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		total := captrivia.PlayerStats{PlayerName: ps.PlayerName}
+		if len(resp) > 0 {
+			if total, err = redisHashToPlayerStats(ps.PlayerName, resp); err != nil {
+				return err
+			}
+		}
+		total = total.Add(ps)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key,
+				"correct_questions", total.CorrectQuestions,
+				"answered_questions", total.AnsweredQuestions,
+				"total_questions", total.TotalQuestions,
+				"time_milliseconds", total.TimeMilliseconds,
+				"last_update", time.Now().UTC().Format(time.RFC3339),
+			)
+			scores := map[captrivia.LeaderboardSort]float64{
+				captrivia.LeaderboardSortAccuracy: total.Accuracy(),
+				captrivia.LeaderboardSortCorrect:  float64(total.CorrectQuestions),
+				captrivia.LeaderboardSortTotal:    float64(total.TotalQuestions),
+			}
+			// players that haven't answered yet have no speed to rank
+			if total.AnsweredQuestions > 0 {
+				scores[captrivia.LeaderboardSortSpeed] = float64(total.AverageMilliseconds())
+			}
+			for sort, score := range scores {
+				pipe.ZAdd(ctx, fmt.Sprintf(leaderboardKey, sort), redis.Z{Score: score, Member: ps.PlayerName})
+			}
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err := s.rdb.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			if err != nil {
+				return fmt.Errorf("error updating stats for player %s: %w", ps.PlayerName, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("stats for player %s kept changing while updating them", ps.PlayerName)
+}
+
+// GetLeaderboard returns a page of player stats ordered by the requested sort.
+// Every sort is descending except average answer time, where lower is better.
+func (s *GameService) GetLeaderboard(q captrivia.LeaderboardQuery) ([]captrivia.PlayerStats, error) {
+	key := fmt.Sprintf(leaderboardKey, q.Sort)
+	start := int64(q.Offset)
+	stop := int64(q.Offset + q.Limit - 1)
+
+	var names []string
+	var err error
+	if q.Sort == captrivia.LeaderboardSortSpeed {
+		names, err = s.rdb.ZRange(ctx, key, start, stop).Result()
+	} else {
+		names, err = s.rdb.ZRevRange(ctx, key, start, stop).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard := make([]captrivia.PlayerStats, 0, len(names))
+	for _, name := range names {
+		resp, err := s.rdb.HGetAll(ctx, fmt.Sprintf(playerStatsKey, name)).Result()
+		if err != nil {
+			return nil, err
+		}
+		stats, err := redisHashToPlayerStats(name, resp)
+		if err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, stats)
+	}
+
+	return leaderboard, nil
+}
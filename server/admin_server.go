@@ -0,0 +1,183 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+)
+
+// maxImportSize caps the body of a bulk question import
+const maxImportSize = 10 << 20
+
+// QuestionReloader reloads the question bank from its source, returning the
+// version loaded.
+type QuestionReloader interface {
+	Reload() (string, error)
+}
+
+// AdminServer serves the admin API used to manage questions. Every request
+// must carry the admin token as a bearer token.
+type AdminServer struct {
+	questions captrivia.QuestionService
+	reloader  QuestionReloader
+	token     string
+}
+
+func NewAdminServer(questions captrivia.QuestionService, reloader QuestionReloader, token string) *AdminServer {
+	return &AdminServer{questions: questions, reloader: reloader, token: token}
+}
+
+// Authorize wraps a handler so that it is only served to requests carrying
+// the admin token.
+func (a *AdminServer) Authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid admin token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Questions writes every question, including retired ones, to the response.
+func (a *AdminServer) Questions(w http.ResponseWriter, r *http.Request) {
+	questions, err := a.questions.GetQuestions()
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, []captrivia.Question{})
+		return
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].ID < questions[j].ID
+	})
+
+	writeJSON(w, http.StatusOK, questions)
+}
+
+func (a *AdminServer) Question(w http.ResponseWriter, r *http.Request) {
+	q, err := a.questions.GetQuestion(r.PathValue("id"))
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, q)
+}
+
+func (a *AdminServer) CreateQuestion(w http.ResponseWriter, r *http.Request) {
+	var q captrivia.Question
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "could not parse question"})
+		return
+	}
+	if err := q.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := a.questions.CreateQuestion(q); err != nil {
+		a.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, q)
+}
+
+// UpdateQuestion replaces the question with the ID in the path.
+func (a *AdminServer) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
+	var q captrivia.Question
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "could not parse question"})
+		return
+	}
+	q.ID = r.PathValue("id")
+	if err := q.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := a.questions.UpdateQuestion(q); err != nil {
+		a.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, q)
+}
+
+// RetireQuestion stops the question being drawn for new games.
+func (a *AdminServer) RetireQuestion(w http.ResponseWriter, r *http.Request) {
+	if err := a.questions.RetireQuestion(r.PathValue("id")); err != nil {
+		a.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportQuestions creates or replaces questions in bulk from a JSON array or
+// CSV body, chosen by the Content-Type header. Nothing is imported unless
+// every question is valid.
+func (a *AdminServer) ImportQuestions(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var questions []captrivia.Question
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		questions, err = captrivia.ParseQuestionsCSV(body)
+	case "application/json", "":
+		err = json.NewDecoder(body).Decode(&questions)
+	default:
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "import must be application/json or text/csv"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := captrivia.ValidateQuestions(questions); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := a.questions.ImportQuestions(questions); err != nil {
+		a.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"imported": len(questions)})
+}
+
+// ReloadQuestions reloads the questions file, swapping it in for new games if
+// it passes validation.
+func (a *AdminServer) ReloadQuestions(w http.ResponseWriter, r *http.Request) {
+	version, err := a.reloader.Reload()
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"version": version})
+}
+
+func (a *AdminServer) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, captrivia.ErrQuestionNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, captrivia.ErrQuestionExists):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+	}
+}
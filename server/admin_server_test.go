@@ -0,0 +1,345 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/dylanconnolly/captrivia-be/server"
+	"github.com/stretchr/testify/assert"
+)
+
+const adminToken = "test admin token"
+
+type MockQuestionService struct {
+	mu        sync.Mutex
+	questions map[string]captrivia.Question
+	fromFile  map[string]bool
+	source    string
+	version   int
+}
+
+func NewMockQuestionService() *MockQuestionService {
+	return &MockQuestionService{questions: make(map[string]captrivia.Question), fromFile: make(map[string]bool)}
+}
+
+func (s *MockQuestionService) GetQuestions() ([]captrivia.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var questions []captrivia.Question
+	for _, q := range s.questions {
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+func (s *MockQuestionService) GetQuestion(id string) (captrivia.Question, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.questions[id]
+	if !ok {
+		return q, captrivia.ErrQuestionNotFound
+	}
+	return q, nil
+}
+
+func (s *MockQuestionService) CreateQuestion(q captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.questions[q.ID]; ok {
+		return captrivia.ErrQuestionExists
+	}
+	s.questions[q.ID] = q
+	s.version++
+	return nil
+}
+
+func (s *MockQuestionService) UpdateQuestion(q captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.questions[q.ID]; !ok {
+		return captrivia.ErrQuestionNotFound
+	}
+	s.questions[q.ID] = q
+	delete(s.fromFile, q.ID)
+	s.version++
+	return nil
+}
+
+func (s *MockQuestionService) RetireQuestion(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q, ok := s.questions[id]
+	if !ok {
+		return captrivia.ErrQuestionNotFound
+	}
+	q.Retired = true
+	s.questions[id] = q
+	delete(s.fromFile, id)
+	s.version++
+	return nil
+}
+
+func (s *MockQuestionService) ImportQuestions(questions []captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range questions {
+		s.questions[q.ID] = q
+		delete(s.fromFile, q.ID)
+	}
+	s.version++
+	return nil
+}
+
+func (s *MockQuestionService) GetQuestionSet() (string, []captrivia.Question, error) {
+	version, _ := s.GetQuestionsVersion()
+	questions, _ := s.GetQuestions()
+	return version, questions, nil
+}
+
+func (s *MockQuestionService) GetQuestionsVersion() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strconv.Itoa(s.version), nil
+}
+
+func (s *MockQuestionService) MergeQuestions(source string, questions []captrivia.Question) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := make([]captrivia.Question, 0, len(s.questions))
+	for _, q := range s.questions {
+		stored = append(stored, q)
+	}
+	merged, fileIDs := captrivia.MergeQuestionsFile(stored, s.fromFile, questions)
+
+	s.questions = make(map[string]captrivia.Question)
+	for _, q := range merged {
+		s.questions[q.ID] = q
+	}
+	s.fromFile = make(map[string]bool)
+	for _, id := range fileIDs {
+		s.fromFile[id] = true
+	}
+	s.source = source
+	s.version++
+	return nil
+}
+
+func (s *MockQuestionService) GetQuestionsSource() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source, nil
+}
+
+func newAdminRouter(qs captrivia.QuestionService) http.Handler {
+	return newAdminRouterWithReloader(qs, nil)
+}
+
+func newAdminRouterWithReloader(qs captrivia.QuestionService, reloader server.QuestionReloader) http.Handler {
+	hub := server.NewHub(MockGameService{}, captrivia.NewServiceQuestionBank(qs), 1, 1)
+	return server.NewRouter(server.NewGameServer(hub), server.NewAdminServer(qs, reloader, adminToken))
+}
+
+func adminRequest(method string, target string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	return req
+}
+
+func TestAdminUnauthorized(t *testing.T) {
+	router := newAdminRouter(NewMockQuestionService())
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/questions", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("Authorization", "Bearer wrong token")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAdminDisabled(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/questions", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminQuestionCRUD(t *testing.T) {
+	qs := NewMockQuestionService()
+	router := newAdminRouter(qs)
+
+	question := `{"id":"1","questionText":"What is a cap table?","options":["a","b"],"correctIndex":1}`
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions", question))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions", question))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPut, "/admin/questions/1", `{"questionText":"What is an option pool?","options":["a","b","c"],"correctIndex":2}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/questions/1", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var q captrivia.Question
+	json.Unmarshal(rec.Body.Bytes(), &q)
+	assert.Equal(t, "What is an option pool?", q.QuestionText)
+	assert.Equal(t, 2, q.CorrectIndex)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPut, "/admin/questions/2", question))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/questions/1", ""))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	retired, _ := qs.GetQuestion("1")
+	assert.True(t, retired.Retired)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/questions/2", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminCreateInvalidQuestion(t *testing.T) {
+	router := newAdminRouter(NewMockQuestionService())
+
+	for _, body := range []string{
+		`{"id":"1","questionText":"one option","options":["a"],"correctIndex":0}`,
+		`{"id":"1","questionText":"out of range","options":["a","b"],"correctIndex":2}`,
+		`{"questionText":"missing id","options":["a","b"],"correctIndex":0}`,
+		`not json`,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions", body))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
+
+func TestAdminImportQuestions(t *testing.T) {
+	qs := NewMockQuestionService()
+	router := newAdminRouter(qs)
+
+	jsonBody := `[{"id":"1","questionText":"q1","options":["a","b"],"correctIndex":0},{"id":"2","questionText":"q2","options":["a","b"],"correctIndex":1}]`
+	req := adminRequest(http.MethodPost, "/admin/questions/import", jsonBody)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"imported":2}`, rec.Body.String())
+
+	csvBody := "id,questionText,options,correctIndex,category,difficulty\n3,q3,a|b|c,2,equity,hard\n"
+	req = adminRequest(http.MethodPost, "/admin/questions/import", csvBody)
+	req.Header.Set("Content-Type", "text/csv")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	q, err := qs.GetQuestion("3")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, q.Options)
+	assert.Equal(t, captrivia.DifficultyHard, q.Difficulty)
+
+	// nothing is imported when any question is invalid
+	duplicates := `[{"id":"4","questionText":"q4","options":["a","b"],"correctIndex":0},{"id":"4","questionText":"q4","options":["a","b"],"correctIndex":0}]`
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/import", duplicates))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	_, err = qs.GetQuestion("4")
+	assert.ErrorIs(t, err, captrivia.ErrQuestionNotFound)
+}
+
+func TestAdminReloadQuestions(t *testing.T) {
+	qs := NewMockQuestionService()
+	path := filepath.Join(t.TempDir(), "questions.json")
+	router := newAdminRouterWithReloader(qs, captrivia.NewQuestionReloader(path, qs))
+
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q1","options":["a","b"],"correctIndex":0}]`), 0o644)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/reload", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"version":"1"}`, rec.Body.String())
+
+	// reloading an unchanged file keeps the version
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/reload", ""))
+	assert.Equal(t, `{"version":"1"}`, rec.Body.String())
+
+	// an invalid file is rejected and the loaded questions are kept
+	os.WriteFile(path, []byte(`[{"id":"2","questionText":"q2","options":["a"],"correctIndex":0}]`), 0o644)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/reload", ""))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	_, err := qs.GetQuestion("1")
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var status map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	assert.Equal(t, "1", status["question_bank_version"])
+}
+
+func TestAdminReloadKeepsAdminQuestions(t *testing.T) {
+	qs := NewMockQuestionService()
+	path := filepath.Join(t.TempDir(), "questions.json")
+	router := newAdminRouterWithReloader(qs, captrivia.NewQuestionReloader(path, qs))
+
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q1","options":["a","b"],"correctIndex":0},{"id":"2","questionText":"q2","options":["a","b"],"correctIndex":0},{"id":"3","questionText":"q3","options":["a","b"],"correctIndex":0}]`), 0o644)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/reload", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions", `{"id":"admin","questionText":"created by an admin","options":["a","b"],"correctIndex":1}`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPut, "/admin/questions/1", `{"questionText":"updated by an admin","options":["a","b"],"correctIndex":1}`))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/questions/2", ""))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// the file changes every question it had and drops the third one
+	os.WriteFile(path, []byte(`[{"id":"1","questionText":"q1 from the file","options":["a","b"],"correctIndex":0},{"id":"2","questionText":"q2 from the file","options":["a","b"],"correctIndex":0},{"id":"4","questionText":"q4","options":["a","b"],"correctIndex":0}]`), 0o644)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/questions/reload", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	created, err := qs.GetQuestion("admin")
+	assert.Nil(t, err)
+	assert.Equal(t, "created by an admin", created.QuestionText)
+
+	updated, _ := qs.GetQuestion("1")
+	assert.Equal(t, "updated by an admin", updated.QuestionText)
+
+	retired, _ := qs.GetQuestion("2")
+	assert.True(t, retired.Retired)
+	assert.Equal(t, "q2", retired.QuestionText)
+
+	_, err = qs.GetQuestion("3")
+	assert.ErrorIs(t, err, captrivia.ErrQuestionNotFound)
+
+	added, err := qs.GetQuestion("4")
+	assert.Nil(t, err)
+	assert.Equal(t, "q4", added.QuestionText)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+const (
+	topicAll   = "captrivia:all"     // PlayerEvents for every client
+	topicLobby = "captrivia:lobby"   // GameEvents for clients not in a game
+	topicNode  = "captrivia:node:%s" // commands and messages for players routed through a single node
+)
+
+// Bus fans messages published on a topic out to every subscriber of that
+// topic. Subscribers may live in other server instances, which is what lets
+// players connected to different instances see each other's games.
+type Bus interface {
+	Publish(topic string, message []byte) error
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// MemoryBus is a Bus for a single server instance.
+type MemoryBus struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan []byte
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscribers: make(map[string][]chan []byte),
+	}
+}
+
+func (b *MemoryBus) Publish(topic string, message []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subscribers[topic] {
+		select {
+		case sub <- message:
+		default:
+			log.Printf("dropping message on topic %s, subscriber is full", topic)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving every message published on the topic
+// until ctx is done, at which point the channel is closed.
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	sub := make(chan []byte, 256)
+
+	b.mu.Lock()
+	b.subscribers[topic] = append(b.subscribers[topic], sub)
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.subscribers[topic]
+		for i, s := range subs {
+			if s == sub {
+				b.subscribers[topic] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(sub)
+	}()
+
+	return sub, nil
+}
+
+type nodeEnvelopeType string
+
+const (
+	// a PlayerCommand sent by a player connected to another node, for a game
+	// owned by this node
+	nodeEnvelopeCommand nodeEnvelopeType = "command"
+	// a message for a player connected to this node, from a game owned by
+	// another node
+	nodeEnvelopeMessage nodeEnvelopeType = "message"
+	// the player connected to another node has left for good
+	nodeEnvelopeDisconnect nodeEnvelopeType = "disconnect"
+)
+
+// nodeEnvelope wraps everything published on a node's topic.
+type nodeEnvelope struct {
+	Type    nodeEnvelopeType `json:"type"`
+	Node    string           `json:"node"` // node that published the envelope
+	Player  string           `json:"player"`
+	Message []byte           `json:"message,omitempty"`
+}
+
+func publishToNode(bus Bus, node string, envelope nodeEnvelope) error {
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return bus.Publish(fmt.Sprintf(topicNode, node), b)
+}
@@ -0,0 +1,142 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/dylanconnolly/captrivia-be/server"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBus(t *testing.T) {
+	bus := server.NewMemoryBus()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sub1, _ := bus.Subscribe(ctx, "topic")
+	sub2, _ := bus.Subscribe(ctx, "topic")
+	other, _ := bus.Subscribe(ctx, "other topic")
+
+	bus.Publish("topic", []byte("message"))
+
+	assert.Equal(t, []byte("message"), <-sub1)
+	assert.Equal(t, []byte("message"), <-sub2)
+	assert.Equal(t, 0, len(other))
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	_, ok := <-sub1
+	assert.False(t, ok)
+	assert.NoError(t, bus.Publish("topic", []byte("after cancel")))
+}
+
+func readUntil[T server.GameEventType | server.PlayerEventType](t *testing.T, ws *websocket.Conn, eventType T) []byte {
+	ws.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("did not receive %s: %s", eventType, err)
+		}
+		var event struct {
+			Type T `json:"type"`
+		}
+		json.Unmarshal(msg, &event)
+		if event.Type == eventType {
+			return msg
+		}
+	}
+}
+
+func TestCommandRoutedToOwningNode(t *testing.T) {
+	bus := server.NewMemoryBus()
+	gameService := MockGameService{owners: &sync.Map{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := server.NewHub(gameService, testQuestionBank, 3, 3)
+	owner.Bus = bus
+	owner.NodeID = "owner"
+	go owner.Run(ctx)
+
+	other := server.NewHub(gameService, testQuestionBank, 3, 3)
+	other.Bus = bus
+	other.NodeID = "other"
+	go other.Run(ctx)
+
+	gh, err := owner.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(other), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=remote+player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+
+	command := server.PlayerCommand{
+		Nonce:   "123456",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}
+	ws.WriteMessage(websocket.TextMessage, toBytes(command))
+
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypePlayerEnter), &enter)
+	assert.Equal(t, gameName, enter.Payload.Name)
+	assert.Equal(t, []string{"remote player"}, enter.Payload.Players)
+}
+
+func TestRemotePlayerDisconnect(t *testing.T) {
+	bus := server.NewMemoryBus()
+	gameService := MockGameService{owners: &sync.Map{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := server.NewHub(gameService, testQuestionBank, 3, 3)
+	owner.Bus = bus
+	owner.NodeID = "owner"
+	go owner.Run(ctx)
+
+	other := server.NewHub(gameService, testQuestionBank, 3, 3)
+	other.Bus = bus
+	other.NodeID = "other"
+	other.ReconnectGrace = 0
+	go other.Run(ctx)
+
+	gh, err := owner.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	ownerServer := httptest.NewServer(server.NewRouter(server.NewGameServer(owner), nil))
+	defer ownerServer.Close()
+	otherServer := httptest.NewServer(server.NewRouter(server.NewGameServer(other), nil))
+	defer otherServer.Close()
+
+	local := joinGame(t, ownerServer, "local player", gh.ID)
+	defer local.Close()
+	remote := joinGame(t, otherServer, "remote player", gh.ID)
+	readUntil(t, local, server.GameEventTypePlayerJoin)
+
+	// the remote player's node tells the owner they have gone, which takes
+	// them out of the game
+	remote.Close()
+	var leave struct {
+		Payload server.GameEventPlayerLobbyAction `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, local, server.GameEventTypePlayerLeave), &leave)
+	assert.Equal(t, "remote player", leave.Payload.Player)
+}
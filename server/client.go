@@ -3,10 +3,15 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
@@ -17,8 +22,29 @@ const (
 	PlayerCommandTypeReady  PlayerCommandType = "ready"
 	PlayerCommandTypeStart  PlayerCommandType = "start"
 	PlayerCommandTypeAnswer PlayerCommandType = "answer"
+
+	PlayerCommandTypeJoinByCode PlayerCommandType = "join_by_code"
+	PlayerCommandTypeSpectate   PlayerCommandType = "spectate"
+
+	// commands only the host of a game may issue
+	PlayerCommandTypeKick           PlayerCommandType = "kick"
+	PlayerCommandTypeTransferHost   PlayerCommandType = "transfer_host"
+	PlayerCommandTypeUpdateSettings PlayerCommandType = "update_settings"
+	PlayerCommandTypeCancel         PlayerCommandType = "cancel"
+	PlayerCommandTypePause          PlayerCommandType = "pause"
+	PlayerCommandTypeResume         PlayerCommandType = "resume"
+	PlayerCommandTypePickTeam       PlayerCommandType = "pick_team"
+
+	// commands of practice games, which a player plays alone at their own pace
+	PlayerCommandTypePractice     PlayerCommandType = "practice"
+	PlayerCommandTypeDaily        PlayerCommandType = "daily" // a practice game with the questions of the day's challenge
+	PlayerCommandTypeNextQuestion PlayerCommandType = "next_question"
 )
 
+// commands repeated with the same nonce within this window are not handled
+// again, the reply to the first is sent instead
+const nonceDedupeWindow = 30 * time.Second
+
 var upgrader = websocket.Upgrader{}
 
 type PlayerCommandType string
@@ -36,20 +62,61 @@ type PlayerCommand struct {
 }
 
 type GameLobbyCommand struct {
-	Player  string
-	Payload PlayerLobbyCommand
-	Type    PlayerCommandType
+	Nonce    string
+	Player   string
+	Payload  PlayerLobbyCommand
+	Type     PlayerCommandType
+	Target   string              // player a kick or transfer_host command is aimed at
+	Team     string              // team a pick_team command puts the player on
+	Settings PlayerCommandCreate // new settings of an update_settings command
 }
 
 type PlayerCommandCreate struct {
-	Name          string `json:"name"`
-	QuestionCount int    `json:"question_count"`
+	Name                   string `json:"name"`
+	captrivia.GameSettings        // question count and timings, scoring, start policy, privacy and question filter
+}
+
+// Payload of the Practice command, the settings that only matter to games
+// with a lobby or several players are ignored
+type PlayerCommandPractice struct {
+	captrivia.GameSettings
+	Ranked bool `json:"ranked"` // count the game's answers towards the player's leaderboard stats
+}
+
+type PlayerCommandJoin struct {
+	GameID         uuid.UUID `json:"game_id"`
+	Password       string    `json:"password,omitempty"`
+	SpectateIfFull bool      `json:"spectate_if_full"` // watch the game instead of being turned away when it is full
+}
+
+// Payload of the JoinByCode command, which is the only way into a private game
+type PlayerCommandJoinByCode struct {
+	Code           string `json:"code"`
+	Password       string `json:"password,omitempty"`
+	SpectateIfFull bool   `json:"spectate_if_full"`
 }
 
 type PlayerLobbyCommand struct {
 	GameID uuid.UUID `json:"game_id"`
 }
 
+// Payload of commands aimed at another player in the game (Kick, TransferHost)
+type PlayerCommandTarget struct {
+	GameID uuid.UUID `json:"game_id"`
+	Player string    `json:"player"`
+}
+
+// Payload of the PickTeam command, sent by a player choosing their team
+type PlayerCommandTeam struct {
+	GameID uuid.UUID `json:"game_id"`
+	Team   string    `json:"team"`
+}
+
+type PlayerCommandSettings struct {
+	GameID uuid.UUID `json:"game_id"`
+	PlayerCommandCreate
+}
+
 type PlayerCommandAnswer struct {
 	GameID     uuid.UUID `json:"game_id"`
 	Index      int       `json:"index"`
@@ -58,27 +125,101 @@ type PlayerCommandAnswer struct {
 
 // Client manages the websocket for a user and communicates with the ClientManager
 type Client struct {
-	name    string
-	gameHub *GameHub
-	hub     *Hub
-	mu      sync.Mutex
-	Conn    WebSocketConn
-	Send    chan []byte
-	closed  bool
+	name     string
+	gameHub  atomic.Pointer[GameHub] // the game the client is in, set and cleared by the GameHub
+	hub      *Hub
+	mu       sync.Mutex
+	Conn     WebSocketConn
+	Send     chan []byte
+	closed   bool
+	connDone chan struct{} // closed when the current websocket connection ends
+
+	// token lets a client that lost its connection reattach a new websocket
+	// while it is detached, within the Hub's reconnect grace window
+	token         string
+	detached      atomic.Bool
+	spectator     atomic.Bool // watching the game without a seat, set when the client joins a game
+	detachedUntil time.Time   // only accessed by Hub.Run
+
+	// forwardedTo tracks the nodes this client sent commands to for games
+	// hosted there
+	forwardedTo map[string]bool
+
+	// set on proxies for players connected to another node, remoteNode is the
+	// node the player is connected to and inbox receives their commands
+	remoteNode string
+	inbox      chan []byte
+
+	// replies holds the reply to each recent command by nonce so that retried
+	// commands are not handled twice
+	repliesMu sync.Mutex
+	replies   map[string]*commandReply
+}
+
+type commandReply struct {
+	message    []byte // nil until the command has been handled
+	receivedAt time.Time
 }
 
 // Creates a new client but does not attach websocket connection. Running serveWebsocket() upgrades connection and begins
 // begins running client
 func NewClient(name string, hub *Hub) *Client {
 	c := &Client{
-		name: name,
-		hub:  hub,
-		Send: make(chan []byte, 256),
+		name:        name,
+		hub:         hub,
+		Send:        make(chan []byte, 256),
+		token:       uuid.NewString(),
+		forwardedTo: make(map[string]bool),
+		replies:     make(map[string]*commandReply),
 	}
 
 	return c
 }
 
+// newProxyClient creates a client standing in for a player connected to
+// another node. Commands arriving in the proxy's inbox are handled as if the
+// player was connected here, and everything sent to the proxy is routed back
+// to the player's node.
+func newProxyClient(ctx context.Context, name string, node string, hub *Hub) *Client {
+	c := NewClient(name, hub)
+	c.remoteNode = node
+	c.inbox = make(chan []byte, 32)
+
+	// the hub closes the inbox when the player disconnects from their node
+	stopped := make(chan struct{})
+	go func() {
+		for message := range c.inbox {
+			c.handleRead(message)
+		}
+		close(stopped)
+	}()
+	go func() {
+		for {
+			var message []byte
+			select {
+			case m, ok := <-c.Send:
+				if !ok {
+					return
+				}
+				message = m
+			case <-stopped:
+				return
+			}
+			envelope := nodeEnvelope{
+				Type:    nodeEnvelopeMessage,
+				Node:    hub.NodeID,
+				Player:  name,
+				Message: message,
+			}
+			if err := publishToNode(hub.Bus, node, envelope); err != nil {
+				log.Printf("error sending message to remote player %s: %s", name, err)
+			}
+		}
+	}()
+
+	return c
+}
+
 func (c *Client) readMessage() {
 	defer c.Close()
 
@@ -102,163 +243,422 @@ func (c *Client) handleRead(message []byte) {
 	err := json.Unmarshal(message, &cmd)
 	if err != nil {
 		log.Printf("error unmarshalling command: %s. Error: %s", message, err)
-		c.Send <- []byte("could not parse command payload")
-		// c.mu.Unlock()
+		c.sendCommandError(cmd, newCommandError(CommandErrorBadPayload, "could not parse command"))
+		return
+	}
+
+	// commands for games hosted by another node are handled by that node
+	if gameID, ok := commandGameID(cmd); ok {
+		if node, remote := c.hub.isRemoteGame(gameID); remote {
+			c.hub.forwardCommand(c, node, message)
+			return
+		}
+	}
+
+	if !c.startCommand(cmd.Nonce) {
 		return
 	}
 
+	if err := c.handleCommand(cmd); err != nil {
+		log.Printf("rejected %s command from %s: %s", cmd.Type, c.name, err)
+		c.sendCommandError(cmd, err)
+	}
+}
+
+// handleCommand carries out a player's command, returning a CommandError if
+// the command is rejected.
+func (c *Client) handleCommand(cmd PlayerCommand) *CommandError {
 	// determine type of incoming message
 	switch cmd.Type {
 	case PlayerCommandTypeCreate:
 		var payload PlayerCommandCreate
-		err := json.Unmarshal(cmd.Payload, &payload)
-		if err != nil {
-			log.Printf("error unmarshalling create game command payload: %s\n Client: %s Command: %s", err, c.name, cmd)
-			c.Send <- []byte("could not parse command payload")
-			// c.mu.Unlock()
-			return
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
 		}
-		c.handleCreateGame(payload)
+		return c.handleCreateGame(cmd, payload)
 
 	case PlayerCommandTypeJoin:
-		var payload PlayerLobbyCommand
-		err := json.Unmarshal(cmd.Payload, &payload)
-		if err != nil {
-			log.Printf("error unmarshalling join game command payload: %s\n Client: %s Command: %s", err, c.name, cmd)
-			c.Send <- []byte("could not parse command payload")
-			// c.mu.Unlock()
-			return
+		var payload PlayerCommandJoin
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
 		}
-		c.handleJoinGame(payload)
+		return c.handleJoinGame(cmd, payload)
 
-	case PlayerCommandTypeReady:
-		var payload PlayerLobbyCommand
-		err := json.Unmarshal(cmd.Payload, &payload)
-		if err != nil {
-			log.Print(err)
-			c.Send <- []byte("error unmarshalling payload for player ready command")
-			// c.mu.Unlock()
+	case PlayerCommandTypeSpectate:
+		var payload PlayerCommandJoin
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
+		}
+		return c.handleSpectate(cmd, payload)
+
+	case PlayerCommandTypeJoinByCode:
+		var payload PlayerCommandJoinByCode
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil || payload.Code == "" {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
+		}
+		return c.handleJoinByCode(cmd, payload)
+
+	case PlayerCommandTypePractice:
+		var payload PlayerCommandPractice
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
 		}
+		return c.handlePractice(cmd, payload)
 
-		c.handlePlayerReady(payload)
+	case PlayerCommandTypeDaily:
+		return c.handleDaily(cmd)
 
-	case PlayerCommandTypeStart:
+	case PlayerCommandTypeReady, PlayerCommandTypeStart, PlayerCommandTypeCancel, PlayerCommandTypeNextQuestion,
+		PlayerCommandTypePause, PlayerCommandTypeResume:
 		var payload PlayerLobbyCommand
-		err := json.Unmarshal(cmd.Payload, &payload)
-		if err != nil {
-			log.Printf("error unmarshalling start game command payload: %s\n Client: %+v Command: %s", err, c, cmd)
-			c.Send <- []byte("could not parse command payload")
-			// c.mu.Unlock()
-			return
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
 		}
+		return c.handleLobbyCommand(cmd, GameLobbyCommand{Payload: payload})
 
-		c.handleStartGame(payload)
+	case PlayerCommandTypeKick, PlayerCommandTypeTransferHost:
+		var payload PlayerCommandTarget
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil || payload.Player == "" {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
+		}
+		return c.handleLobbyCommand(cmd, GameLobbyCommand{
+			Payload: PlayerLobbyCommand{GameID: payload.GameID},
+			Target:  payload.Player,
+		})
+
+	case PlayerCommandTypePickTeam:
+		var payload PlayerCommandTeam
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil || payload.Team == "" {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
+		}
+		return c.handleLobbyCommand(cmd, GameLobbyCommand{
+			Payload: PlayerLobbyCommand{GameID: payload.GameID},
+			Team:    payload.Team,
+		})
+
+	case PlayerCommandTypeUpdateSettings:
+		var payload PlayerCommandSettings
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
+		}
+		return c.handleLobbyCommand(cmd, GameLobbyCommand{
+			Payload:  PlayerLobbyCommand{GameID: payload.GameID},
+			Settings: payload.PlayerCommandCreate,
+		})
 
 	case PlayerCommandTypeAnswer:
 		var payload PlayerCommandAnswer
-		err := json.Unmarshal(cmd.Payload, &payload)
-		if err != nil {
-			log.Printf("error unmarshalling player answer: %s\n Client: %+v Command: %s", err, c, cmd)
-			c.Send <- []byte("could not parse command payload")
-			// c.mu.Unlock()
-			return
+		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+			return newCommandError(CommandErrorBadPayload, "could not parse command payload")
 		}
+		return c.handlePlayerAnswer(cmd, payload)
+	}
 
-		c.handlePlayerAnswer(payload)
-	default:
-		log.Printf("got unknown command %s", cmd)
+	return newCommandError(CommandErrorUnknownCommand, fmt.Sprintf("unknown command %q", cmd.Type))
+}
+
+// sendCommandError tells the player their command was rejected.
+func (c *Client) sendCommandError(cmd PlayerCommand, err *CommandError) {
+	c.reply(cmd.Nonce, newPlayerEventCommandError(c.name, cmd, err).toBytes())
+}
+
+// ack tells the player their command was handled and the state of the game
+// it was for.
+func (c *Client) ack(cmd PlayerCommand, gameID uuid.UUID, state captrivia.GameState) {
+	c.reply(cmd.Nonce, newPlayerEventCommandAck(c.name, cmd, gameID, state).toBytes())
+}
+
+// startCommand records that the command with the nonce is being handled. It
+// returns false for a command repeated within the dedupe window, resending
+// the first command's reply if it has been sent.
+func (c *Client) startCommand(nonce string) bool {
+	if nonce == "" {
+		return true
+	}
+
+	c.repliesMu.Lock()
+	now := time.Now()
+	for n, r := range c.replies {
+		if now.Sub(r.receivedAt) > nonceDedupeWindow {
+			delete(c.replies, n)
+		}
+	}
+	previous, ok := c.replies[nonce]
+	if !ok {
+		c.replies[nonce] = &commandReply{receivedAt: now}
+	}
+	c.repliesMu.Unlock()
+
+	if !ok {
+		return true
+	}
+	if previous.message != nil {
+		c.Send <- previous.message
 	}
+	return false
 }
 
-func (c *Client) handleCreateGame(payload PlayerCommandCreate) {
+// reply sends the reply to a command, keeping it in case the command is
+// repeated.
+func (c *Client) reply(nonce string, message []byte) {
+	if nonce != "" {
+		c.repliesMu.Lock()
+		if r, ok := c.replies[nonce]; ok {
+			r.message = message
+		}
+		c.repliesMu.Unlock()
+	}
+
+	c.Send <- message
+}
+
+// commandGameID returns the game a command is for, if it is for one.
+func commandGameID(cmd PlayerCommand) (uuid.UUID, bool) {
+	var payload struct {
+		GameID uuid.UUID `json:"game_id"`
+	}
+	if err := json.Unmarshal(cmd.Payload, &payload); err != nil || payload.GameID == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return payload.GameID, true
+}
+
+func (c *Client) handleCreateGame(cmd PlayerCommand, payload PlayerCommandCreate) *CommandError {
 	// creates GameHub which manages the state and lifecycle of the game
-	gameHub, err := c.hub.NewGameHub(payload.Name, payload.QuestionCount)
+	gameHub, err := c.hub.NewGameHub(payload.Name, payload.GameSettings)
 	if err != nil {
 		log.Println(err)
-		return
+		return newCommandError(CommandErrorBadPayload, err.Error())
 	}
 
+	// the creator is seated, and so made host, before anyone else can see
+	// the game and join it
+	c.spectator.Store(false)
+	gameHub.game.AddPlayer(c.name)
+	c.hub.announceGame(gameHub)
 	go gameHub.Run(context.Background())
 
+	c.ack(cmd, gameHub.ID, gameHub.game.CurrentState())
 	gameHub.Register <- c
+	return nil
 }
 
-func (c *Client) handleJoinGame(payload PlayerLobbyCommand) {
-	gh, err := c.hub.GetGameHub(payload.GameID)
+// handlePractice starts a practice game for the player. The player is seated
+// straight away and moves on to each question, starting with the first, with
+// next_question.
+func (c *Client) handlePractice(cmd PlayerCommand, payload PlayerCommandPractice) *CommandError {
+	gameHub, err := c.hub.NewPracticeGameHub(c.name, payload.GameSettings, payload.Ranked)
 	if err != nil {
 		log.Println(err)
-		return
+		return newCommandError(CommandErrorBadPayload, err.Error())
 	}
 
-	gh.Register <- c
+	go gameHub.Run(context.Background())
+
+	c.spectator.Store(false)
+	gameHub.game.AddPlayer(c.name)
+	c.ack(cmd, gameHub.ID, gameHub.game.CurrentState())
+	gameHub.Register <- c
+	return nil
 }
 
-func (c *Client) handlePlayerReady(payload PlayerLobbyCommand) {
-	gameCommand := GameLobbyCommand{
-		Player:  c.name,
-		Payload: payload,
-		Type:    PlayerCommandTypeReady,
+// handleDaily starts a practice game with the questions of the day's
+// challenge, which each player may play once.
+func (c *Client) handleDaily(cmd PlayerCommand) *CommandError {
+	gameHub, err := c.hub.NewDailyGameHub(c.name)
+	if errors.Is(err, captrivia.ErrDailyPlayed) {
+		return newCommandError(CommandErrorAlreadyPlayed, err.Error())
 	}
-
-	gh, err := c.hub.GetGameHub(payload.GameID)
 	if err != nil {
 		log.Println(err)
-		return
+		return newCommandError(CommandErrorInvalidState, err.Error())
+	}
+
+	go gameHub.Run(context.Background())
+
+	c.spectator.Store(false)
+	gameHub.game.AddPlayer(c.name)
+	c.ack(cmd, gameHub.ID, gameHub.game.CurrentState())
+	gameHub.Register <- c
+	return nil
+}
+
+func (c *Client) handleJoinGame(cmd PlayerCommand, payload PlayerCommandJoin) *CommandError {
+	gh, cmdErr := c.findGameHub(cmd, payload)
+	if cmdErr != nil {
+		return cmdErr
+	}
+	// players can't be dropped into the middle of a game, only players with a
+	// seat can come back to a game that has started
+	if gh.game.State != captrivia.GameStateWaiting && !gh.game.HasPlayer(c.name) {
+		return newCommandError(CommandErrorInvalidState, "game has already started, it can only be spectated")
 	}
 
-	// if c.gameHub == nil {
-	// 	gh.register <- c
-	// }
+	// the seat is taken here so players joining at the same time can't both
+	// take the last one
+	c.spectator.Store(false)
+	if err := gh.game.AddPlayer(c.name); errors.Is(err, captrivia.ErrGameFull) {
+		if !payload.SpectateIfFull {
+			return newCommandError(CommandErrorGameFull, fmt.Sprintf("game has no free seats, it is limited to %d players", gh.game.MaxPlayers))
+		}
+		gh.game.AddSpectator(c.name)
+		c.spectator.Store(true)
+	}
 
-	gh.Commands <- gameCommand
+	c.ack(cmd, gh.ID, gh.game.State)
+	gh.Register <- c
+	return nil
 }
 
-func (c *Client) handleStartGame(payload PlayerLobbyCommand) {
-	gameCommand := GameLobbyCommand{
-		Player:  c.name,
-		Payload: payload,
-		Type:    PlayerCommandTypeStart,
+// handleSpectate attaches the client to a game, waiting or running, as a
+// viewer that receives the game's events but can't answer. Players with a
+// seat in the game keep it.
+func (c *Client) handleSpectate(cmd PlayerCommand, payload PlayerCommandJoin) *CommandError {
+	gh, cmdErr := c.findGameHub(cmd, payload)
+	if cmdErr != nil {
+		return cmdErr
 	}
 
+	c.spectator.Store(!gh.game.HasPlayer(c.name))
+	if c.spectator.Load() {
+		gh.game.AddSpectator(c.name)
+	}
+
+	c.ack(cmd, gh.ID, gh.game.State)
+	gh.Register <- c
+	return nil
+}
+
+// findGameHub returns the GameHub a join or spectate command is for, if the
+// player is allowed into the game.
+func (c *Client) findGameHub(cmd PlayerCommand, payload PlayerCommandJoin) (*GameHub, *CommandError) {
 	gh, err := c.hub.GetGameHub(payload.GameID)
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, newCommandError(CommandErrorGameNotFound, err.Error())
+	}
+	// private games are hidden from players without the invite code, players
+	// with a seat can always come back to their game
+	if gh.game.Private && cmd.Type != PlayerCommandTypeJoinByCode && !gh.game.HasPlayer(c.name) {
+		return nil, newCommandError(CommandErrorGameNotFound, fmt.Sprintf("no gamehub found for gameID=%s", payload.GameID))
+	}
+	// practice games are only ever seen by their player
+	if gh.game.IsPractice() && !gh.game.HasPlayer(c.name) {
+		return nil, newCommandError(CommandErrorGameNotFound, fmt.Sprintf("no gamehub found for gameID=%s", payload.GameID))
+	}
+	if !gh.game.CheckPassword(payload.Password) {
+		return nil, newCommandError(CommandErrorWrongPassword, "wrong password for game")
+	}
+	return gh, nil
+}
+
+// handleJoinByCode joins the player to the game the invite code is for. Codes
+// for games hosted by another node are passed on to that node.
+func (c *Client) handleJoinByCode(cmd PlayerCommand, payload PlayerCommandJoinByCode) *CommandError {
+	gameID, err := c.hub.GameService.GetInviteCodeGame(captrivia.NormalizeInviteCode(payload.Code))
+	if err != nil {
+		log.Printf("error looking up invite code %s: %s", payload.Code, err)
+	}
+	if err != nil || gameID == uuid.Nil {
+		return newCommandError(CommandErrorGameNotFound, "no game found for invite code")
+	}
+
+	if node, remote := c.hub.isRemoteGame(gameID); remote {
+		message, _ := json.Marshal(cmd)
+		c.hub.forwardCommand(c, node, message)
+		return nil
+	}
+
+	return c.handleJoinGame(cmd, PlayerCommandJoin{
+		GameID:         gameID,
+		Password:       payload.Password,
+		SpectateIfFull: payload.SpectateIfFull,
+	})
+}
+
+// handleLobbyCommand passes a lobby command (Ready, Start and the host
+// commands) to the GameHub, which acknowledges it, or rejects it if the player
+// may not issue it or the game is not in a state to accept it.
+func (c *Client) handleLobbyCommand(cmd PlayerCommand, gameCommand GameLobbyCommand) *CommandError {
+	gameCommand.Nonce = cmd.Nonce
+	gameCommand.Player = c.name
+	gameCommand.Type = cmd.Type
+
+	gh, err := c.hub.GetGameHub(gameCommand.Payload.GameID)
+	if err != nil {
+		return newCommandError(CommandErrorGameNotFound, err.Error())
+	}
+	// the GameHub of a finished game no longer reads commands
+	if state := gh.game.CurrentState(); state.Finished() {
+		return newCommandError(CommandErrorInvalidState, fmt.Sprintf("game is %s", state))
+	}
+	// the GameHub checks again, this check answers players that aren't in
+	// the game at all and so can't be replied to by it
+	if !gh.game.HasPlayer(c.name) && !gh.game.IsSpectator(c.name) {
+		return newCommandError(CommandErrorNotPlayer, "only players in the game can "+string(cmd.Type)+" it")
 	}
 
-	gh.Commands <- gameCommand
+	// a game that finishes while the command is waiting stops reading
+	// commands, the read loop is let go rather than left waiting on it
+	select {
+	case gh.Commands <- gameCommand:
+	case <-gh.stopped:
+		return newCommandError(CommandErrorInvalidState, "the game is over")
+	}
+	return nil
 }
 
-func (c *Client) handlePlayerAnswer(payload PlayerCommandAnswer) {
+func (c *Client) handlePlayerAnswer(cmd PlayerCommand, payload PlayerCommandAnswer) *CommandError {
 	ga := GameAnswer{
 		QuestionID: payload.QuestionID,
 		Player:     c.name,
 		Index:      payload.Index,
+		ReceivedAt: time.Now(),
 	}
 
 	gh, err := c.hub.GetGameHub(payload.GameID)
 	if err != nil {
-		log.Println(err)
-		return
+		return newCommandError(CommandErrorGameNotFound, err.Error())
+	}
+	if c.spectator.Load() {
+		return newCommandError(CommandErrorNotPlayer, "spectators can not answer")
 	}
 
-	gh.Answers <- ga
+	// the game decides whether the answer counts once it gets to it, an
+	// answer that is too late or early is rejected with an answer_rejected
+	// event. The read loop never waits on a game that is busy or over.
+	select {
+	case <-gh.stopped:
+		return newCommandError(CommandErrorGameNotFound, "the game is over")
+	default:
+	}
+	select {
+	case gh.Answers <- ga:
+	default:
+		return newCommandError(CommandErrorInvalidState, "the game is not taking answers right now")
+	}
+	c.ack(cmd, gh.ID, gh.game.CurrentState())
+	return nil
 }
 
-func (c *Client) writeMessage() {
-	defer c.Conn.Close()
-	for message := range c.Send {
-		// if !ok {
-		// 	c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-		// 	return
-		// }
-		err := c.Conn.WriteMessage(websocket.TextMessage, message)
-		if err != nil {
-			log.Printf("error writing message to websocket: ERROR=%s. MESSAGE=%s, CLIENT=%+v\n", err, message, c)
+func (c *Client) writeMessage(conn WebSocketConn, done <-chan struct{}) {
+	defer conn.Close()
+	for {
+		select {
+		case message, ok := <-c.Send:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			err := conn.WriteMessage(websocket.TextMessage, message)
+			if err != nil {
+				log.Printf("error writing message to websocket: ERROR=%s. MESSAGE=%s, CLIENT=%+v\n", err, message, c)
+			}
+		case <-done:
+			// the connection was lost, leave anything still queued on Send for
+			// the writer of the next connection if the client reconnects
+			return
 		}
 	}
-
-	c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
 }
 
 // upgrades connection to websocket on client and registers client with client Hub
@@ -274,22 +674,64 @@ func (c *Client) ServeWebsocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Printf("client connected: %s", c.name)
-	c.Conn = conn
+	done := c.attach(conn)
 	c.hub.register <- c
 	pe := newPlayerEventConnect(c.name)
 	c.hub.allBroadcast <- pe.toBytes()
 
 	go c.readMessage()
-	go c.writeMessage()
+	go c.writeMessage(conn, done)
+}
+
+// Reconnect upgrades a new connection to websocket for a client that was
+// detached from its previous connection, keeping its name and game seat.
+func (c *Client) Reconnect(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("error upgrading connection: %s\n", err)
+		return
+	}
+	log.Printf("client reconnected: %s", c.name)
+	done := c.attach(conn)
+	c.hub.reconnect <- c
+	pe := newPlayerEventConnect(c.name)
+	c.hub.allBroadcast <- pe.toBytes()
+
+	go c.readMessage()
+	go c.writeMessage(conn, done)
+}
+
+// attach sets conn as the client's websocket connection and sends the client
+// the session token it can use to reconnect.
+func (c *Client) attach(conn WebSocketConn) chan struct{} {
+	c.mu.Lock()
+	c.Conn = conn
+	c.closed = false
+	c.connDone = make(chan struct{})
+	done := c.connDone
+	c.mu.Unlock()
+
+	se := newPlayerEventSession(c.name, c.token, c.hub.ReconnectGrace)
+	c.Send <- se.toBytes()
+
+	return done
 }
 
 func (c *Client) Close() {
 	c.mu.Lock()
 	c.closed = true
+	conn := c.Conn
+	if c.connDone != nil {
+		select {
+		case <-c.connDone:
+		default:
+			close(c.connDone)
+		}
+	}
 	c.mu.Unlock()
 	log.Printf("player %s disconnect - client connection closed", c.name)
 	c.hub.disconnect <- c
 	pe := newPlayerEventDisconnect(c.name)
 	c.hub.allBroadcast <- pe.toBytes()
-	c.Conn.Close()
+	conn.Close()
 }
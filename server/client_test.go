@@ -6,8 +6,12 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/dylanconnolly/captrivia-be/server"
@@ -22,7 +26,25 @@ const (
 	questionCount = 4
 )
 
-type MockGameService struct{}
+// games with this ID do not exist in the MockGameService
+var missingGameID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+var testQuestionBank = mustLoadQuestionBank("../questions.json")
+
+func mustLoadQuestionBank(path string) *captrivia.MemoryQuestionBank {
+	bank, err := captrivia.LoadQuestionBank(path)
+	if err != nil {
+		log.Fatalf("couldn't load question bank for tests: %s", err)
+	}
+	return bank
+}
+
+type MockGameService struct {
+	snapshots []captrivia.GameSnapshot
+	owners    *sync.Map // game ID -> node, games have no owner when nil
+	invites   *sync.Map // invite code -> game ID, every code is free when nil
+	daily     *sync.Map // date and player -> daily result, nothing is played when nil
+}
 
 func (s MockGameService) GetGames() ([]captrivia.RepositoryGame, error) {
 	game := captrivia.RepositoryGame{
@@ -32,18 +54,149 @@ func (s MockGameService) GetGames() ([]captrivia.RepositoryGame, error) {
 		QuestionCount: 3,
 		State:         captrivia.GameStateWaiting,
 	}
+	private := captrivia.RepositoryGame{
+		ID:            uuid.New(),
+		Name:          "private game",
+		PlayerCount:   1,
+		QuestionCount: 3,
+		Private:       true,
+		State:         captrivia.GameStateWaiting,
+	}
 
-	return []captrivia.RepositoryGame{game}, nil
+	return []captrivia.RepositoryGame{game, private}, nil
 }
 
 func (s MockGameService) SaveGame(g *captrivia.Game) error {
 	return nil
 }
 
+func (s MockGameService) GetGameSnapshots() ([]captrivia.GameSnapshot, error) {
+	return s.snapshots, nil
+}
+
 func (s MockGameService) ExpireGame(g uuid.UUID) error {
 	return nil
 }
 
+func (s MockGameService) SetGameOwner(gameID uuid.UUID, node string) error {
+	if s.owners != nil {
+		s.owners.Store(gameID, node)
+	}
+	return nil
+}
+
+func (s MockGameService) GetGameOwner(gameID uuid.UUID) (string, error) {
+	if s.owners == nil {
+		return "", nil
+	}
+	owner, _ := s.owners.Load(gameID)
+	node, _ := owner.(string)
+	return node, nil
+}
+
+func (s MockGameService) ReserveInviteCode(code string, gameID uuid.UUID) (bool, error) {
+	if s.invites == nil {
+		return true, nil
+	}
+	_, taken := s.invites.LoadOrStore(code, gameID)
+	return !taken, nil
+}
+
+func (s MockGameService) GetInviteCodeGame(code string) (uuid.UUID, error) {
+	if s.invites == nil {
+		return uuid.Nil, nil
+	}
+	id, _ := s.invites.Load(code)
+	gameID, _ := id.(uuid.UUID)
+	return gameID, nil
+}
+
+func (s MockGameService) SaveAnswerLog(gameID uuid.UUID, answers []captrivia.AnswerRecord) error {
+	return nil
+}
+
+func (s MockGameService) GetAnswerLog(gameID uuid.UUID) ([]captrivia.AnswerRecord, error) {
+	if gameID == missingGameID {
+		return nil, nil
+	}
+	answers := []captrivia.AnswerRecord{
+		{Player: "player 1", QuestionID: "4", Index: 1, Correct: true, Accepted: true, Points: 1, LatencyMs: 1200},
+	}
+	return answers, nil
+}
+
+func (s MockGameService) UpdatePlayerStats(stats []captrivia.PlayerStats) error {
+	return nil
+}
+
+func (s MockGameService) GetLeaderboard(q captrivia.LeaderboardQuery) ([]captrivia.PlayerStats, error) {
+	stats := []captrivia.PlayerStats{
+		{PlayerName: "player 1", CorrectQuestions: 3, AnsweredQuestions: 4, TotalQuestions: 4, TimeMilliseconds: 4000},
+		{PlayerName: "player 2", CorrectQuestions: 1, AnsweredQuestions: 3, TotalQuestions: 4, TimeMilliseconds: 6000},
+	}
+	if q.Offset >= len(stats) {
+		return nil, nil
+	}
+	return stats[q.Offset:min(len(stats), q.Offset+q.Limit)], nil
+}
+
+func (s MockGameService) SaveDailyResult(result captrivia.DailyResult) error {
+	if s.daily != nil {
+		s.daily.LoadOrStore(result.Date+"/"+result.PlayerName, result)
+	}
+	return nil
+}
+
+func (s MockGameService) ReserveDaily(date string, player string) (bool, error) {
+	if s.daily == nil {
+		return true, nil
+	}
+	_, played := s.daily.LoadOrStore("played/"+date+"/"+player, true)
+	return !played, nil
+}
+
+func (s MockGameService) PinDailyQuestions(date string, ids []string) ([]string, error) {
+	if s.daily == nil {
+		return ids, nil
+	}
+	pinned, _ := s.daily.LoadOrStore("questions/"+date, ids)
+	return pinned.([]string), nil
+}
+
+func (s MockGameService) GetDailyQuestions(date string) ([]string, bool, error) {
+	if s.daily == nil {
+		return nil, false, nil
+	}
+	pinned, ok := s.daily.Load("questions/" + date)
+	if !ok {
+		return nil, false, nil
+	}
+	return pinned.([]string), true, nil
+}
+
+func (s MockGameService) GetDailyLeaderboard(date string, offset int, limit int) ([]captrivia.DailyResult, error) {
+	results := []captrivia.DailyResult{}
+	if s.daily != nil {
+		s.daily.Range(func(_, v any) bool {
+			if result, ok := v.(captrivia.DailyResult); ok && result.Date == date {
+				results = append(results, result)
+			}
+			return true
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RankScore() > results[j].RankScore()
+	})
+	if offset >= len(results) {
+		return []captrivia.DailyResult{}, nil
+	}
+	results = results[offset:min(len(results), offset+limit)]
+	for i := range results {
+		results[i].Rank = offset + i + 1
+	}
+	return results, nil
+}
+
 func buildEvent(resp []byte, v server.EventPayload) server.GameEvent {
 	var event server.GameEvent
 	event.Payload = v
@@ -51,8 +204,20 @@ func buildEvent(resp []byte, v server.EventPayload) server.GameEvent {
 	return event
 }
 
+type sessionEvent struct {
+	Payload server.PlayerEventSession `json:"payload"`
+	Player  string                    `json:"player"`
+	Type    server.PlayerEventType    `json:"type"`
+}
+
+func readSession(resp []byte) sessionEvent {
+	var event sessionEvent
+	json.Unmarshal(resp, &event)
+	return event
+}
+
 func openWebsocketConn(t *testing.T) (*websocket.Conn, *httptest.Server, server.Client) {
-	hub := server.NewHub(MockGameService{}, 1, 1)
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
 	ctx, _ := context.WithCancel(context.Background())
 	go hub.Run(ctx)
 
@@ -71,7 +236,8 @@ func openWebsocketConn(t *testing.T) (*websocket.Conn, *httptest.Server, server.
 		t.Fatalf("error dialing websocket: %s", err)
 	}
 
-	// ignore player_connected message
+	// ignore player_session and player_connected messages
+	ws.ReadMessage()
 	ws.ReadMessage()
 
 	return ws, s, *client
@@ -93,10 +259,10 @@ func toBytes(command server.PlayerCommand) []byte {
 
 func Setup(t *testing.T) (uuid.UUID, *websocket.Conn, *server.Client, context.CancelFunc) {
 
-	hub := server.NewHub(MockGameService{}, 3, 3)
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
 	ctx, cancel := context.WithCancel(context.Background())
 	go hub.Run(ctx)
-	gh, err := hub.NewGameHub(gameName, questionCount)
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
 	if err != nil {
 		log.Println(err)
 	}
@@ -116,7 +282,8 @@ func Setup(t *testing.T) (uuid.UUID, *websocket.Conn, *server.Client, context.Ca
 		t.Fatalf("error dialing websocket: %s", err)
 	}
 
-	// ignore player_connected message
+	// ignore player_session and player_connected messages
+	ws.ReadMessage()
 	ws.ReadMessage()
 	go gh.Run(ctx)
 
@@ -124,7 +291,7 @@ func Setup(t *testing.T) (uuid.UUID, *websocket.Conn, *server.Client, context.Ca
 }
 
 func TestServeWebsocket(t *testing.T) {
-	hub := server.NewHub(MockGameService{}, 1, 1)
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
 	ctx, _ := context.WithCancel(context.Background())
 	go hub.Run(ctx)
 
@@ -149,6 +316,12 @@ func TestServeWebsocket(t *testing.T) {
 		t.Fatalf("error writing to websocket: %s", err)
 	}
 
+	// the client is sent its session token before anything else
+	_, resp, _ := ws.ReadMessage()
+	session := readSession(resp)
+	assert.Equal(t, server.PlayerEventTypeSession, session.Type)
+	assert.NotEmpty(t, session.Payload.Token)
+
 	expected := server.PlayerEvent{
 		Payload: server.EmptyPayload{}.Raw(),
 		Player:  playerName,
@@ -157,21 +330,41 @@ func TestServeWebsocket(t *testing.T) {
 
 	expJSON, _ := json.Marshal(expected)
 
-	_, resp, _ := ws.ReadMessage()
+	_, resp, _ = ws.ReadMessage()
 
 	assert.Equal(t, string(expJSON), string(resp))
 	client.Close()
 }
 
 func TestPlayerCommandCreate(t *testing.T) {
-	gameID, ws, client, cancel := Setup(t)
-	// ws, _, _ := Setup(t)
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	// the creator leaves the lobby for their game as it is created, the game
+	// is announced to the players still in the lobby
+	lobby, _, err := dialConnect(s, "name=lobby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lobby.Close()
+	readUntil(t, lobby, server.PlayerEventTypeConnect)
+
+	ws, _, err := dialConnect(s, "name="+url.QueryEscape(playerName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
 
 	command := server.PlayerCommand{
 		Nonce: "123456",
 		Payload: Raw(server.PlayerCommandCreate{
-			Name:          gameName,
-			QuestionCount: questionCount,
+			Name:         gameName,
+			GameSettings: captrivia.GameSettings{QuestionCount: questionCount},
 		}),
 		Type: server.PlayerCommandTypeCreate,
 	}
@@ -180,24 +373,45 @@ func TestPlayerCommandCreate(t *testing.T) {
 
 	ws.WriteMessage(websocket.TextMessage, b)
 
-	expected := server.GameEvent{
-		ID: gameID,
-		Payload: server.GameEventCreate{
-			Name:          gameName,
-			QuestionCount: questionCount,
-		}.Raw(),
-		Type: server.GameEventTypeCreate,
-	}
-
-	_, r, _ := ws.ReadMessage()
-
-	resp := buildEvent(r, server.GameEventCreate{}.Raw())
-
-	assert.Equal(t, expected.Payload, resp.Payload)
-	assert.Equal(t, expected.Type, expected.Type)
+	ack := readCommandAck(t, ws)
+	assert.Equal(t, "123456", ack.Nonce)
+	assert.Equal(t, server.PlayerCommandTypeCreate, ack.Command)
+	assert.Equal(t, captrivia.GameStateWaiting, ack.State)
 
-	client.Close()
-	cancel()
+	var created struct {
+		ID      uuid.UUID              `json:"id"`
+		Payload server.GameEventCreate `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, lobby, server.GameEventTypeCreate), &created)
+
+	public := false
+	assert.Equal(t, ack.GameID, created.ID)
+	assert.Equal(t, server.GameEventCreate{
+		Name: gameName,
+		GameSettings: captrivia.GameSettings{
+			QuestionCount:    questionCount,
+			Mode:             captrivia.GameModeClassic,
+			QuestionSeconds:  3,
+			CountdownSeconds: 3,
+			RevealSeconds:    3,
+			ScoringMode:      captrivia.ScoringModeFirstCorrect,
+			StartPolicy:      captrivia.StartPolicy{Mode: captrivia.StartPolicyManual},
+			Private:          &public,
+		},
+	}, created.Payload)
+
+	// the creator already holds the host's seat when the game is announced
+	lobby.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "lobby-join",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: created.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, lobby, server.GameEventTypePlayerEnter), &enter)
+	assert.Equal(t, playerName, enter.Payload.Host)
+	assert.Contains(t, enter.Payload.Players, playerName)
 }
 
 func TestPlayerCommandJoin(t *testing.T) {
@@ -219,18 +433,36 @@ func TestPlayerCommandJoin(t *testing.T) {
 
 	pmap[playerName] = false
 
+	// the join is acknowledged before the player enters the game
+	_, r, _ := ws.ReadMessage()
+	var ack struct {
+		Payload server.PlayerEventCommandAck `json:"payload"`
+		Type    server.PlayerEventType       `json:"type"`
+	}
+	json.Unmarshal(r, &ack)
+	assert.Equal(t, server.PlayerEventTypeCommandAck, ack.Type)
+	assert.Equal(t, server.PlayerEventCommandAck{
+		Nonce:   "123456",
+		Command: server.PlayerCommandTypeJoin,
+		GameID:  gameID,
+		State:   captrivia.GameStateWaiting,
+	}, ack.Payload)
+
 	expected := server.GameEvent{
 		ID: gameID,
 		Payload: server.GameEventPlayerEnter{
 			Name:          gameName,
+			Host:          playerName,
 			Players:       []string{playerName},
 			PlayersReady:  pmap,
 			QuestionCount: questionCount,
+			State:         captrivia.GameStateWaiting,
+			Scores:        []captrivia.PlayerScore{{Name: playerName, Score: 0}},
 		}.Raw(),
 		Type: server.GameEventTypePlayerEnter,
 	}
 
-	_, r, _ := ws.ReadMessage()
+	_, r, _ = ws.ReadMessage()
 
 	resp := buildEvent(r, server.GameEventCreate{}.Raw())
 
@@ -256,3 +488,201 @@ func TestPlayerCommandJoin(t *testing.T) {
 	client.Close()
 	cancel()
 }
+
+func dialConnect(s *httptest.Server, query string) (*websocket.Conn, *http.Response, error) {
+	u := "ws" + strings.TrimPrefix(s.URL, "http") + "/connect?" + query
+
+	header := http.Header{}
+	header.Add("Origin", "http://localhost:3000")
+
+	return websocket.DefaultDialer.Dial(u, header)
+}
+
+func TestReconnectWithToken(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=reconnecting+player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	_, resp, _ := ws.ReadMessage()
+	session := readSession(resp)
+	assert.Equal(t, server.PlayerEventTypeSession, session.Type)
+	ws.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the name is held for the player while they are disconnected
+	_, httpResp, err := dialConnect(s, "name=reconnecting+player")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, httpResp.StatusCode)
+
+	_, httpResp, err = dialConnect(s, "token=not-a-token")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+
+	ws, _, err = dialConnect(s, "token="+session.Payload.Token)
+	if err != nil {
+		t.Fatalf("error reconnecting websocket: %s", err)
+	}
+	defer ws.Close()
+
+	_, resp, _ = ws.ReadMessage()
+	resumed := readSession(resp)
+	assert.Equal(t, server.PlayerEventTypeSession, resumed.Type)
+	assert.Equal(t, "reconnecting player", resumed.Player)
+	assert.Equal(t, session.Payload.Token, resumed.Payload.Token)
+
+	// a connected session can't be taken over
+	_, httpResp, err = dialConnect(s, "token="+session.Payload.Token)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusConflict, httpResp.StatusCode)
+}
+
+func TestReconnectGraceExpires(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	hub.ReconnectGrace = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=expiring+player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	_, resp, _ := ws.ReadMessage()
+	session := readSession(resp)
+	ws.Close()
+
+	time.Sleep(300 * time.Millisecond)
+
+	_, httpResp, err := dialConnect(s, "token="+session.Payload.Token)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, httpResp.StatusCode)
+	assert.False(t, hub.NameTaken("expiring player"))
+}
+
+func TestPlayerCommandStartTwice(t *testing.T) {
+	gameID, ws, client, cancel := Setup(t)
+	defer cancel()
+	defer client.Close()
+
+	lobbyCommand := func(nonce string, commandType server.PlayerCommandType) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+			Type:    commandType,
+		})
+	}
+
+	ws.WriteMessage(websocket.TextMessage, lobbyCommand("1", server.PlayerCommandTypeJoin))
+	readUntil(t, ws, server.GameEventTypePlayerJoin)
+
+	ws.WriteMessage(websocket.TextMessage, lobbyCommand("2", server.PlayerCommandTypeStart))
+	readUntil(t, ws, server.GameEventTypeStart)
+
+	// a second start is rejected rather than starting a second game loop
+	ws.WriteMessage(websocket.TextMessage, lobbyCommand("3", server.PlayerCommandTypeStart))
+	event := readCommandError(t, ws)
+	assert.Equal(t, "3", event.Nonce)
+	assert.Equal(t, server.PlayerCommandTypeStart, event.Command)
+	assert.Equal(t, server.CommandErrorInvalidState, event.Code)
+}
+
+func readCommandError(t *testing.T, ws *websocket.Conn) server.PlayerEventCommandError {
+	resp := readUntil(t, ws, server.PlayerEventTypeCommandError)
+
+	var event struct {
+		Payload server.PlayerEventCommandError `json:"payload"`
+	}
+	json.Unmarshal(resp, &event)
+	return event.Payload
+}
+
+func TestCommandErrors(t *testing.T) {
+	gameID, ws, client, cancel := Setup(t)
+	defer cancel()
+	defer client.Close()
+
+	tests := []struct {
+		name    string
+		command string
+		code    server.CommandErrorCode
+	}{
+		{"not json", `not json`, server.CommandErrorBadPayload},
+		{"bad payload", `{"nonce":"1","type":"join","payload":{"game_id":5}}`, server.CommandErrorBadPayload},
+		{"unknown command", `{"nonce":"2","type":"dance","payload":{}}`, server.CommandErrorUnknownCommand},
+		{"missing game", `{"nonce":"3","type":"join","payload":{"game_id":"` + missingGameID.String() + `"}}`, server.CommandErrorGameNotFound},
+		{"bad create settings", `{"nonce":"5","type":"create","payload":{"name":"game","question_count":51}}`, server.CommandErrorBadPayload},
+	}
+
+	for _, tt := range tests {
+		ws.WriteMessage(websocket.TextMessage, []byte(tt.command))
+		event := readCommandError(t, ws)
+		assert.Equal(t, tt.code, event.Code, tt.name)
+	}
+
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"nonce":"abc","type":"dance","payload":{}}`))
+	event := readCommandError(t, ws)
+	assert.Equal(t, "abc", event.Nonce)
+	assert.Equal(t, server.PlayerCommandType("dance"), event.Command)
+
+	// answers are handed to the game, which rejects them if no question is
+	// being displayed
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"nonce":"4","type":"answer","payload":{"game_id":"`+gameID.String()+`","index":0}}`))
+	ack := readCommandAck(t, ws)
+	assert.Equal(t, "4", ack.Nonce)
+	assert.Equal(t, captrivia.GameStateWaiting, ack.State)
+}
+
+func readCommandAck(t *testing.T, ws *websocket.Conn) server.PlayerEventCommandAck {
+	resp := readUntil(t, ws, server.PlayerEventTypeCommandAck)
+
+	var event struct {
+		Payload server.PlayerEventCommandAck `json:"payload"`
+	}
+	json.Unmarshal(resp, &event)
+	return event.Payload
+}
+
+func TestCommandAckDedupe(t *testing.T) {
+	gameID, ws, client, cancel := Setup(t)
+	defer cancel()
+	defer client.Close()
+
+	join := toBytes(server.PlayerCommand{
+		Nonce:   "join-1",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+		Type:    server.PlayerCommandTypeJoin,
+	})
+	ws.WriteMessage(websocket.TextMessage, join)
+	readUntil(t, ws, server.GameEventTypePlayerJoin)
+
+	ready := toBytes(server.PlayerCommand{
+		Nonce:   "ready-1",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+		Type:    server.PlayerCommandTypeReady,
+	})
+	ws.WriteMessage(websocket.TextMessage, ready)
+	ack := readCommandAck(t, ws)
+	assert.Equal(t, "ready-1", ack.Nonce)
+	assert.Equal(t, gameID, ack.GameID)
+	assert.Equal(t, captrivia.GameStateWaiting, ack.State)
+
+	// a retried command gets the same reply and is not handled again
+	ws.WriteMessage(websocket.TextMessage, ready)
+	assert.Equal(t, ack, readCommandAck(t, ws))
+
+	ws.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, msg, err := ws.ReadMessage()
+	assert.Error(t, err, "unexpected message %s", msg)
+}
@@ -2,7 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/google/uuid"
@@ -16,6 +18,11 @@ const (
 	PlayerEventTypeConnect    PlayerEventType = "player_connect"
 	PlayerEventTypeDisconnect PlayerEventType = "player_disconnect"
 
+	// event types sent only to the player they are about
+	PlayerEventTypeSession      PlayerEventType = "player_session"
+	PlayerEventTypeCommandAck   PlayerEventType = "command_ack"
+	PlayerEventTypeCommandError PlayerEventType = "command_error"
+
 	// event types broadcasted to anyone not in a game
 	GameEventTypeCreate      GameEventType = "game_create"
 	GameEventTypeStateChange GameEventType = "game_state_change"
@@ -23,18 +30,61 @@ const (
 	GameEventTypeDestroy     GameEventType = "game_destroy"
 
 	// event types broadcasted to active game participants
-	GameEventTypeStart           GameEventType = "game_start"
-	GameEventTypeEnd             GameEventType = "game_end"
-	GameEventTypeCountdown       GameEventType = "game_countdown"
-	GameEventTypeQuestion        GameEventType = "game_question"
-	GameEventTypePlayerEnter     GameEventType = "game_player_enter"
-	GameEventTypePlayerJoin      GameEventType = "game_player_join"
-	GameEventTypePlayerReady     GameEventType = "game_player_ready"
-	GameEventTypePlayerLeave     GameEventType = "game_player_leave"
-	GameEventTypePlayerCorrect   GameEventType = "game_player_correct"
-	GameEventTypePlayerIncorrect GameEventType = "game_player_incorrect"
+	GameEventTypeStart            GameEventType = "game_start"
+	GameEventTypeEnd              GameEventType = "game_end"
+	GameEventTypeCountdown        GameEventType = "game_countdown"
+	GameEventTypeQuestion         GameEventType = "game_question"
+	GameEventTypeQuestionReveal   GameEventType = "game_question_reveal"
+	GameEventTypePlayerEnter      GameEventType = "game_player_enter"
+	GameEventTypePlayerJoin       GameEventType = "game_player_join"
+	GameEventTypePlayerReady      GameEventType = "game_player_ready"
+	GameEventTypePlayerLeave      GameEventType = "game_player_leave"
+	GameEventTypePlayerCorrect    GameEventType = "game_player_correct"
+	GameEventTypePlayerIncorrect  GameEventType = "game_player_incorrect"
+	GameEventTypePlayerKick       GameEventType = "game_player_kick"
+	GameEventTypeHostChange       GameEventType = "game_host_change"
+	GameEventTypeSettings         GameEventType = "game_settings"
+	GameEventTypeCancel           GameEventType = "game_cancel"
+	GameEventTypePause            GameEventType = "game_pause"
+	GameEventTypeResume           GameEventType = "game_resume" // the state a paused game is back in and the time left on it
+	GameEventTypeLobbyTimer       GameEventType = "game_lobby_timer"
+	GameEventTypeSpectatorJoin    GameEventType = "game_spectator_join"
+	GameEventTypeSpectatorLeave   GameEventType = "game_spectator_leave"
+	GameEventTypeTeamPick         GameEventType = "game_team_pick"
+	GameEventTypeLives            GameEventType = "game_lives"             // lives left in a survival game after each question
+	GameEventTypePlayerEliminated GameEventType = "game_player_eliminated" // a survival game player is out of lives and can only watch
+	GameEventTypeTeams            GameEventType = "game_teams"             // the teams the game is played in, sent when it starts
+	GameEventTypeAnswerRejected   GameEventType = "game_answer_rejected"   // sent only to the player whose answer was rejected
+)
+
+type CommandErrorCode string
+
+const (
+	CommandErrorBadPayload     CommandErrorCode = "bad_payload"
+	CommandErrorGameNotFound   CommandErrorCode = "game_not_found"
+	CommandErrorGameFull       CommandErrorCode = "game_full"
+	CommandErrorNotHost        CommandErrorCode = "not_host"
+	CommandErrorInvalidState   CommandErrorCode = "invalid_state"
+	CommandErrorUnknownCommand CommandErrorCode = "unknown_command"
+	CommandErrorWrongPassword  CommandErrorCode = "wrong_password"
+	CommandErrorNotPlayer      CommandErrorCode = "not_player"
+	CommandErrorAlreadyPlayed  CommandErrorCode = "already_played"
 )
 
+// CommandError is the reason a player's command was rejected.
+type CommandError struct {
+	Code    CommandErrorCode
+	Message string
+}
+
+func newCommandError(code CommandErrorCode, message string) *CommandError {
+	return &CommandError{Code: code, Message: message}
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
 type EventPayload interface {
 	json.Marshaler
 }
@@ -75,8 +125,9 @@ func (e PlayerEvent) toBytes() []byte {
 
 // Payload to be sent to client when a new game is created
 type GameEventCreate struct {
-	Name          string `json:"name"`
-	QuestionCount int    `json:"question_count"`
+	Name                   string `json:"name"`
+	HasPassword            bool   `json:"has_password"`
+	captrivia.GameSettings        // never includes the password
 }
 
 func (e GameEventCreate) Raw() *json.RawMessage {
@@ -102,11 +153,22 @@ func (e GameEventPlayerLobbyAction) Raw() *json.RawMessage {
 	return &raw
 }
 
+// Sent to a player when they enter a game lobby, and replayed when they
+// reconnect to a game that is in progress
 type GameEventPlayerEnter struct {
-	Name          string          `json:"name"`
-	Players       []string        `json:"players"`
-	PlayersReady  map[string]bool `json:"players_ready"`
-	QuestionCount int             `json:"question_count"`
+	Name          string                  `json:"name"`
+	Host          string                  `json:"host"`
+	InviteCode    string                  `json:"invite_code,omitempty"`
+	Spectator     bool                    `json:"spectator,omitempty"` // the player entered the game as a spectator
+	Spectators    []string                `json:"spectators,omitempty"`
+	Teams         []captrivia.TeamScore   `json:"teams,omitempty"`
+	Lives         map[string]int          `json:"lives,omitempty"`
+	Players       []string                `json:"players"`
+	PlayersReady  map[string]bool         `json:"players_ready"`
+	QuestionCount int                     `json:"question_count"`
+	State         captrivia.GameState     `json:"state"`
+	Question      *GameEventQuestion      `json:"question,omitempty"`
+	Scores        []captrivia.PlayerScore `json:"scores"`
 }
 
 func (e GameEventPlayerEnter) Raw() *json.RawMessage {
@@ -118,8 +180,69 @@ func (e GameEventPlayerEnter) Raw() *json.RawMessage {
 	return &raw
 }
 
+// Sent to the game and the lobby when the host changes the game's settings
+type GameEventSettings struct {
+	Name                   string `json:"name"`
+	HasPassword            bool   `json:"has_password"`
+	captrivia.GameSettings        // never includes the password
+}
+
+func (e GameEventSettings) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+// Sent to the game and the lobby when an auto start game's lobby timer starts,
+// and to players entering the game while it runs
+type GameEventLobbyTimer struct {
+	Seconds     int                   `json:"seconds"`
+	StartPolicy captrivia.StartPolicy `json:"start_policy"`
+}
+
+func (e GameEventLobbyTimer) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+type GameEventResume struct {
+	State   captrivia.GameState `json:"state"`
+	Seconds int                 `json:"seconds"`
+}
+
+func (e GameEventResume) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+type PlayerEventSession struct {
+	Token                 string `json:"token"`
+	ReconnectGraceSeconds int    `json:"reconnect_grace_seconds"`
+}
+
+func (e PlayerEventSession) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
 type GameEventPlayerCount struct {
-	PlayerCount int `json:"player_count"`
+	PlayerCount    int `json:"player_count"`
+	SpectatorCount int `json:"spectator_count"`
 }
 
 func (e GameEventPlayerCount) Raw() *json.RawMessage {
@@ -144,6 +267,42 @@ func (e GameEventStateChange) Raw() *json.RawMessage {
 	return &raw
 }
 
+// Sent to a player when a command they issued has been handled, with the game
+// the command was for and the state it is in
+type PlayerEventCommandAck struct {
+	Nonce   string              `json:"nonce"`
+	Command PlayerCommandType   `json:"command"`
+	GameID  uuid.UUID           `json:"game_id"`
+	State   captrivia.GameState `json:"state"`
+}
+
+func (e PlayerEventCommandAck) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+// Sent to a player when a command they issued is rejected. Nonce and Command
+// identify the command that was rejected.
+type PlayerEventCommandError struct {
+	Nonce   string            `json:"nonce"`
+	Command PlayerCommandType `json:"command"`
+	Code    CommandErrorCode  `json:"code"`
+	Message string            `json:"message"`
+}
+
+func (e PlayerEventCommandError) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
 type GameEventCountdown struct {
 	Seconds int `json:"seconds"`
 }
@@ -173,9 +332,24 @@ func (e GameEventQuestion) Raw() *json.RawMessage {
 	return &raw
 }
 
+type GameEventQuestionReveal struct {
+	captrivia.QuestionReveal     // correct answer, explanation and how players answered
+	Seconds                  int `json:"seconds"`
+}
+
+func (e GameEventQuestionReveal) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
 type GameEventPlayerAnswer struct {
 	QuestionID string `json:"id"`
 	Player     string `json:"player"`
+	Points     int    `json:"points"`
 }
 
 func (e GameEventPlayerAnswer) Raw() *json.RawMessage {
@@ -187,8 +361,67 @@ func (e GameEventPlayerAnswer) Raw() *json.RawMessage {
 	return &raw
 }
 
+type GameEventAnswerRejected struct {
+	QuestionID string                    `json:"id"`
+	Reason     captrivia.AnswerRejection `json:"reason"`
+}
+
+func (e GameEventAnswerRejected) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+type GameEventTeamPick struct {
+	Player string `json:"player"`
+	Team   string `json:"team"`
+}
+
+func (e GameEventTeamPick) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+type GameEventTeams struct {
+	Teams []captrivia.TeamScore `json:"teams"`
+}
+
+func (e GameEventTeams) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
+type GameEventLives struct {
+	Lives    map[string]int `json:"lives"`
+	LostLife []string       `json:"lost_life"` // players that lost a life on the last question
+}
+
+func (e GameEventLives) Raw() *json.RawMessage {
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(bytes)
+	return &raw
+}
+
 type GameEventEnd struct {
-	Scores []captrivia.PlayerScore `json:"scores"`
+	ScoringMode captrivia.ScoringMode      `json:"scoring_mode"`
+	Scores      []captrivia.PlayerScore    `json:"scores"`
+	Teams       []captrivia.TeamScore      `json:"teams,omitempty"`     // standings of the teams in a team game
+	Survivors   []string                   `json:"survivors,omitempty"` // players of a survival game with lives left
+	Practice    *captrivia.PracticeSummary `json:"practice,omitempty"`  // how the player of a practice game did
 }
 
 func (e GameEventEnd) Raw() *json.RawMessage {
@@ -211,13 +444,14 @@ func (e EmptyPayload) Raw() *json.RawMessage {
 	return &raw
 }
 
-func newGameEventCreate(gameID uuid.UUID, gameName string, gameQCount int) GameEvent {
+func newGameEventCreate(game *captrivia.Game) GameEvent {
 	payload := GameEventCreate{
-		Name:          gameName,
-		QuestionCount: gameQCount,
+		Name:         game.Name,
+		HasPassword:  game.HasPassword(),
+		GameSettings: game.Settings(),
 	}
 	ge := GameEvent{
-		ID:      gameID,
+		ID:      game.ID,
 		Payload: payload.Raw(),
 		Type:    GameEventTypeCreate,
 	}
@@ -234,11 +468,37 @@ func newGameEvent(gameID uuid.UUID, payload EventPayload, eventType GameEventTyp
 }
 
 func newGameEventPlayerEnter(player string, game *captrivia.Game) GameEvent {
+	// the game keeps running while the player enters, the payload is built
+	// from a snapshot of it
+	snapshot := game.Snapshot()
+	players := make([]string, 0, len(snapshot.PlayersReady))
+	for name := range snapshot.PlayersReady {
+		players = append(players, name)
+	}
+
 	payload := GameEventPlayerEnter{
-		Name:          game.Name,
-		Players:       game.PlayerNames(),
-		PlayersReady:  game.PlayersReady,
-		QuestionCount: game.QuestionCount,
+		Spectator:     game.IsSpectator(player),
+		Spectators:    game.SpectatorNames(),
+		Teams:         game.TeamScores(),
+		Lives:         game.LivesLeft(),
+		Name:          snapshot.Name,
+		Host:          snapshot.Host,
+		InviteCode:    snapshot.InviteCode,
+		Players:       players,
+		PlayersReady:  snapshot.PlayersReady,
+		QuestionCount: snapshot.QuestionCount,
+		State:         snapshot.State,
+		Scores:        game.PlayerScores(),
+	}
+	if snapshot.State == captrivia.GameStateQuestion {
+		q := snapshot.Questions[snapshot.CurrentQuestionIndex]
+		remaining := time.Until(snapshot.Deadline).Round(time.Second)
+		payload.Question = &GameEventQuestion{
+			ID:       q.ID,
+			Options:  q.Options,
+			Question: q.QuestionText,
+			Seconds:  int(max(remaining, 0).Seconds()),
+		}
 	}
 
 	ge := newGameEvent(game.ID, payload.Raw(), GameEventTypePlayerEnter)
@@ -276,12 +536,104 @@ func newGameEventPlayerLeave(gameID uuid.UUID, player string) GameEvent {
 	return ge
 }
 
-func newGameEventPlayerCount(gameID uuid.UUID, count int) GameEvent {
+func newGameEventSpectatorJoin(gameID uuid.UUID, player string) GameEvent {
+	payload := GameEventPlayerLobbyAction{
+		Player: player,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeSpectatorJoin)
+
+	return ge
+}
+
+func newGameEventSpectatorLeave(gameID uuid.UUID, player string) GameEvent {
+	payload := GameEventPlayerLobbyAction{
+		Player: player,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeSpectatorLeave)
+
+	return ge
+}
+
+func newGameEventPlayerKick(gameID uuid.UUID, player string) GameEvent {
+	payload := GameEventPlayerLobbyAction{
+		Player: player,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePlayerKick)
+
+	return ge
+}
+
+// player is the new host of the game
+func newGameEventHostChange(gameID uuid.UUID, player string) GameEvent {
+	payload := GameEventPlayerLobbyAction{
+		Player: player,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeHostChange)
+
+	return ge
+}
+
+func newGameEventSettings(game *captrivia.Game) GameEvent {
+	payload := GameEventSettings{
+		Name:         game.Name,
+		HasPassword:  game.HasPassword(),
+		GameSettings: game.Settings(),
+	}
+
+	ge := newGameEvent(game.ID, payload.Raw(), GameEventTypeSettings)
+
+	return ge
+}
+
+func newGameEventCancel(gameID uuid.UUID) GameEvent {
+	payload := EmptyPayload{}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeCancel)
+
+	return ge
+}
+
+func newGameEventPause(gameID uuid.UUID) GameEvent {
+	payload := EmptyPayload{}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePause)
+
+	return ge
+}
+
+func newGameEventResume(gameID uuid.UUID, state captrivia.GameState, remaining time.Duration) GameEvent {
+	payload := GameEventResume{
+		State:   state,
+		Seconds: int(max(remaining.Round(time.Second), 0).Seconds()),
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeResume)
+
+	return ge
+}
+
+func newGameEventLobbyTimer(gameID uuid.UUID, policy captrivia.StartPolicy, remaining time.Duration) GameEvent {
+	payload := GameEventLobbyTimer{
+		Seconds:     int(max(remaining.Round(time.Second), 0).Seconds()),
+		StartPolicy: policy,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeLobbyTimer)
+
+	return ge
+}
+
+func newGameEventPlayerCount(game *captrivia.Game) GameEvent {
 	payload := GameEventPlayerCount{
-		PlayerCount: count,
+		PlayerCount:    game.PlayerCount,
+		SpectatorCount: game.SpectatorCount(),
 	}
 
-	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePlayerCount)
+	ge := newGameEvent(game.ID, payload.Raw(), GameEventTypePlayerCount)
 
 	return ge
 }
@@ -327,10 +679,22 @@ func newGameEventQuestion(gameID uuid.UUID, question captrivia.Question, duratio
 	return ge
 }
 
-func newGameEventPlayerCorrect(gameID uuid.UUID, player string, questionID string) GameEvent {
+func newGameEventQuestionReveal(gameID uuid.UUID, reveal captrivia.QuestionReveal, duration int) GameEvent {
+	payload := GameEventQuestionReveal{
+		QuestionReveal: reveal,
+		Seconds:        duration,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeQuestionReveal)
+
+	return ge
+}
+
+func newGameEventPlayerCorrect(gameID uuid.UUID, player string, questionID string, points int) GameEvent {
 	payload := GameEventPlayerAnswer{
 		QuestionID: questionID,
 		Player:     player,
+		Points:     points,
 	}
 
 	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePlayerCorrect)
@@ -338,10 +702,22 @@ func newGameEventPlayerCorrect(gameID uuid.UUID, player string, questionID strin
 	return ge
 }
 
-func newGameEventPlayerIncorrect(gameID uuid.UUID, player string, questionID string) GameEvent {
+func newGameEventAnswerRejected(gameID uuid.UUID, questionID string, reason captrivia.AnswerRejection) GameEvent {
+	payload := GameEventAnswerRejected{
+		QuestionID: questionID,
+		Reason:     reason,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeAnswerRejected)
+
+	return ge
+}
+
+func newGameEventPlayerIncorrect(gameID uuid.UUID, player string, questionID string, points int) GameEvent {
 	payload := GameEventPlayerAnswer{
 		QuestionID: questionID,
 		Player:     player,
+		Points:     points,
 	}
 
 	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePlayerIncorrect)
@@ -349,12 +725,61 @@ func newGameEventPlayerIncorrect(gameID uuid.UUID, player string, questionID str
 	return ge
 }
 
-func newGameEventEnd(gameID uuid.UUID, scores []captrivia.PlayerScore) GameEvent {
+func newGameEventTeamPick(gameID uuid.UUID, player string, team string) GameEvent {
+	payload := GameEventTeamPick{
+		Player: player,
+		Team:   team,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeTeamPick)
+
+	return ge
+}
+
+func newGameEventTeams(gameID uuid.UUID, teams []captrivia.TeamScore) GameEvent {
+	payload := GameEventTeams{
+		Teams: teams,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeTeams)
+
+	return ge
+}
+
+func newGameEventLives(gameID uuid.UUID, lives map[string]int, lost []string) GameEvent {
+	payload := GameEventLives{
+		Lives:    lives,
+		LostLife: lost,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeLives)
+
+	return ge
+}
+
+func newGameEventPlayerEliminated(gameID uuid.UUID, player string) GameEvent {
+	payload := GameEventPlayerLobbyAction{
+		Player: player,
+	}
+
+	ge := newGameEvent(gameID, payload.Raw(), GameEventTypePlayerEliminated)
+
+	return ge
+}
+
+func newGameEventEnd(game *captrivia.Game) GameEvent {
 	payload := GameEventEnd{
-		Scores: scores,
+		ScoringMode: game.ScoringMode,
+		Scores:      game.PlayerScores(),
+		Teams:       game.TeamScores(),
+		Survivors:   game.Survivors(),
+	}
+	if game.IsPractice() {
+		summary := game.PracticeSummary(game.Host)
+		payload.Practice = &summary
 	}
 
-	ge := newGameEvent(gameID, payload.Raw(), GameEventTypeEnd)
+	ge := newGameEvent(game.ID, payload.Raw(), GameEventTypeEnd)
 
 	return ge
 }
@@ -375,6 +800,43 @@ func newPlayerEventConnect(player string) PlayerEvent {
 	return pe
 }
 
+func newPlayerEventSession(player string, token string, grace time.Duration) PlayerEvent {
+	payload := PlayerEventSession{
+		Token:                 token,
+		ReconnectGraceSeconds: int(grace.Seconds()),
+	}
+
+	pe := newPlayerEvent(player, payload.Raw(), PlayerEventTypeSession)
+
+	return pe
+}
+
+func newPlayerEventCommandAck(player string, cmd PlayerCommand, gameID uuid.UUID, state captrivia.GameState) PlayerEvent {
+	payload := PlayerEventCommandAck{
+		Nonce:   cmd.Nonce,
+		Command: cmd.Type,
+		GameID:  gameID,
+		State:   state,
+	}
+
+	pe := newPlayerEvent(player, payload.Raw(), PlayerEventTypeCommandAck)
+
+	return pe
+}
+
+func newPlayerEventCommandError(player string, cmd PlayerCommand, err *CommandError) PlayerEvent {
+	payload := PlayerEventCommandError{
+		Nonce:   cmd.Nonce,
+		Command: cmd.Type,
+		Code:    err.Code,
+		Message: err.Message,
+	}
+
+	pe := newPlayerEvent(player, payload.Raw(), PlayerEventTypeCommandError)
+
+	return pe
+}
+
 func newPlayerEventDisconnect(player string) PlayerEvent {
 	payload := EmptyPayload{}
 
@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -10,10 +12,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// answerQueueSize is how many answers can wait on the game loop before more
+// are turned away.
+const answerQueueSize = 25
+
 type GameHub struct {
 	ID           uuid.UUID
 	Answers      chan GameAnswer
 	Broadcast    chan []byte
+	abandoned    chan struct{} // signalled when the player of a practice game leaves it
+	cancelled    chan struct{} // closed when the host cancels a running game
 	Clients      map[*Client]bool
 	Commands     chan GameLobbyCommand
 	countdownSec int
@@ -21,23 +29,31 @@ type GameHub struct {
 	gameService  captrivia.GameService
 	gameEnded    <-chan bool
 	hubBroadcast chan<- GameEvent // send only channel to push GameEvents to Hub
+	lobbyTimer   <-chan time.Time // fires when an auto start game's lobby period is over, only used by Run
 	mu           sync.Mutex
+	pauses       chan GameLobbyCommand  // pause and resume commands, handled by RunGame as it owns the game's timers
+	questionBank captrivia.QuestionBank // questions are redrawn from it when the host changes the settings
 	Register     chan *Client
 	Unregister   chan *Client
 	questionSec  int
+	revealSec    int           // the answer is revealed for this long after each question
+	stopped      chan struct{} // closed when Run returns
 }
 
 type GameAnswer struct {
 	QuestionID string
 	Player     string
 	Index      int
+	ReceivedAt time.Time
 }
 
 func NewGameHub(g *captrivia.Game, gameService captrivia.GameService, hubBroadcast chan<- GameEvent, countdownSec int, questionSec int) *GameHub {
 	return &GameHub{
 		ID:           g.ID,
-		Answers:      make(chan GameAnswer),
+		abandoned:    make(chan struct{}, 1),
+		Answers:      make(chan GameAnswer, answerQueueSize),
 		Broadcast:    make(chan []byte, 50),
+		cancelled:    make(chan struct{}),
 		Clients:      make(map[*Client]bool),
 		Commands:     make(chan GameLobbyCommand),
 		countdownSec: countdownSec,
@@ -45,8 +61,11 @@ func NewGameHub(g *captrivia.Game, gameService captrivia.GameService, hubBroadca
 		gameService:  gameService,
 		gameEnded:    g.GameEndedChan(),
 		hubBroadcast: hubBroadcast,
+		pauses:       make(chan GameLobbyCommand, 5),
 		Register:     make(chan *Client, 5),
 		questionSec:  questionSec,
+		revealSec:    g.RevealSeconds,
+		stopped:      make(chan struct{}),
 		Unregister:   make(chan *Client, 5),
 	}
 }
@@ -54,44 +73,62 @@ func NewGameHub(g *captrivia.Game, gameService captrivia.GameService, hubBroadca
 // Run() handles client connections and message directives such
 // as register, unregister, command, broadcast
 func (g *GameHub) Run(ctx context.Context) {
+	defer close(g.stopped)
 	done := make(chan bool, 1)
+
+	// practice games are played at the player's pace rather than on RunGame's
+	// timers, their answers are handled here
+	var practiceAnswers <-chan GameAnswer
+	if g.game.IsPractice() {
+		practiceAnswers = g.Answers
+	} else if g.game.InProgress() {
+		// games recovered from a snapshot pick up where they left off
+		go g.RunGame(done)
+	}
+
+	// auto start games start themselves when the lobby timer expires
+	if g.game.State == captrivia.GameStateWaiting && g.game.StartPolicy.HasLobbyTimer() {
+		g.lobbyTimer = g.startLobbyTimer()
+	}
+
 	for {
 		select {
 		case client := <-g.Register:
 			g.mu.Lock()
 			g.Clients[client] = true
 			g.mu.Unlock()
-			client.gameHub = g
+			client.gameHub.Store(g)
 			go g.playerJoin(client)
 		case client := <-g.Unregister:
 			go g.playerLeave(client)
 		case message := <-g.Broadcast:
-			// broadcasts message to all clients that are part of the GameHub
-			g.mu.Lock()
-			for client := range g.Clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(g.Clients, client)
-				}
-			}
-			g.mu.Unlock()
+			g.broadcast(message)
 
 		case command := <-g.Commands:
-			// commands channel listens for lobby commands (Ready, Start, Leave) issued by player clients
-			var event GameEvent
-			switch command.Type {
-			case PlayerCommandTypeReady:
-				event = newGameEventPlayerReady(command.Payload.GameID, command.Player)
-				g.game.PlayerReady(command.Player)
-				go g.gameService.SaveGame(g.game)
-			case PlayerCommandTypeStart:
-				event = newGameEventStart(command.Payload.GameID)
+			// commands channel listens for lobby commands (Ready, Start and the
+			// host commands) issued by player clients
+			g.handleCommand(command, done)
+
+		case <-g.lobbyTimer:
+			g.lobbyTimer = nil
+			if g.game.State != captrivia.GameStateWaiting {
+				continue
+			}
+			if g.game.PlayerCount == 0 {
+				// nobody is waiting to play, give players another lobby period
+				g.game.SetDeadline(time.Time{})
+				g.lobbyTimer = g.startLobbyTimer()
+				continue
+			}
+			g.startGame(done)
+
+		case ans := <-practiceAnswers:
+			g.handlePracticeAnswer(ans)
 
-				go g.RunGame(done)
+		case <-g.abandoned:
+			if err := g.ChangeGameState(captrivia.GameStateAborted); err == nil {
+				done <- true
 			}
-			g.Broadcast <- event.toBytes()
 
 		case <-done:
 			err := g.gameService.ExpireGame(g.ID)
@@ -100,10 +137,19 @@ func (g *GameHub) Run(ctx context.Context) {
 				log.Printf("error setting game to expire %s . GameID=%s", err, g.game.ID)
 			}
 
+			// deliver anything still queued, such as the end of the game,
+			// before the clients leave
+			for len(g.Broadcast) > 0 {
+				g.broadcast(<-g.Broadcast)
+			}
+
 			// re-register clients with Hub to recieve game creation/state updates and remove from GameHub clients
 			g.mu.Lock()
 			for client := range g.Clients {
-				client.hub.register <- client
+				client.gameHub.Store(nil)
+				if !client.detached.Load() {
+					client.hub.register <- client
+				}
 				delete(g.Clients, client)
 			}
 			g.mu.Unlock()
@@ -112,26 +158,316 @@ func (g *GameHub) Run(ctx context.Context) {
 	}
 }
 
+// broadcast sends the message to all clients that are part of the GameHub
+func (g *GameHub) broadcast(message []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for client := range g.Clients {
+		if client.detached.Load() {
+			// the player's seat is held while they reconnect, they are
+			// sent the game state when they come back
+			continue
+		}
+		select {
+		case client.Send <- message:
+		default:
+			close(client.Send)
+			delete(g.Clients, client)
+		}
+	}
+}
+
+// InviteCode is the code players join the game with if it is private.
+func (g *GameHub) InviteCode() string {
+	return g.game.InviteCode
+}
+
+// broadcastLobby sends the event to the players in the Hub lobby. Private
+// and practice games are kept out of the lobby.
+func (g *GameHub) broadcastLobby(event GameEvent) {
+	if g.game.Private || g.game.IsPractice() {
+		return
+	}
+	g.hubBroadcast <- event
+}
+
+// handleCommand applies a lobby command to the game, acknowledging it to the
+// player that issued it and broadcasting the result to the game, or rejecting
+// it if the player is not allowed to issue it.
+func (g *GameHub) handleCommand(command GameLobbyCommand, done chan<- bool) {
+	if g.game.IsSpectator(command.Player) {
+		g.rejectCommand(command, newCommandError(CommandErrorNotPlayer, "spectators can not "+string(command.Type)+" the game"))
+		return
+	}
+	if !g.game.HasPlayer(command.Player) {
+		g.rejectCommand(command, newCommandError(CommandErrorNotPlayer, "only players in the game can "+string(command.Type)+" it"))
+		return
+	}
+	if command.Type != PlayerCommandTypeReady && command.Type != PlayerCommandTypePickTeam && !g.game.IsHost(command.Player) {
+		g.rejectCommand(command, newCommandError(CommandErrorNotHost, "only the host can "+string(command.Type)+" the game"))
+		return
+	}
+
+	var event GameEvent
+	switch command.Type {
+	case PlayerCommandTypeReady:
+		event = newGameEventPlayerReady(command.Payload.GameID, command.Player)
+		g.game.PlayerReady(command.Player)
+		go g.gameService.SaveGame(g.game)
+		g.ackCommand(command)
+		g.Broadcast <- event.toBytes()
+
+		// auto start games start as soon as every player is ready
+		if g.game.State == captrivia.GameStateWaiting && g.game.StartPolicy.AutoStart(g.game.ReadyCount(), g.game.PlayerCount) {
+			g.startGame(done)
+		}
+		return
+
+	case PlayerCommandTypeStart:
+		if g.game.IsPractice() {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, "practice games are played with next_question"))
+			return
+		}
+		if err := g.game.CanStart(); err != nil {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+			return
+		}
+		// the transition fails if the game has already started, so only
+		// one RunGame loop is ever running
+		if err := g.startGame(done); err != nil {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+			return
+		}
+		g.ackCommand(command)
+		return
+
+	case PlayerCommandTypeNextQuestion:
+		ended, err := g.nextPracticeQuestion()
+		if err != nil {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+			return
+		}
+		g.ackCommand(command)
+		if ended {
+			g.handleGameEnd()
+			done <- true
+		}
+		return
+
+	case PlayerCommandTypePickTeam:
+		if err := g.game.PickTeam(command.Player, command.Team); err != nil {
+			code := CommandErrorBadPayload
+			if errors.Is(err, captrivia.ErrGameStarted) {
+				code = CommandErrorInvalidState
+			}
+			g.rejectCommand(command, newCommandError(code, err.Error()))
+			return
+		}
+		go g.gameService.SaveGame(g.game)
+		event = newGameEventTeamPick(g.game.ID, command.Player, command.Team)
+		g.ackCommand(command)
+
+	case PlayerCommandTypeKick:
+		if command.Target == command.Player {
+			g.rejectCommand(command, newCommandError(CommandErrorBadPayload, "the host can not kick themselves"))
+			return
+		}
+		if !g.game.HasPlayer(command.Target) {
+			g.rejectCommand(command, newCommandError(CommandErrorBadPayload, "player is not in the game: "+command.Target))
+			return
+		}
+		event = newGameEventPlayerKick(g.game.ID, command.Target)
+		g.ackCommand(command)
+		g.kickPlayer(command.Target, event)
+
+	case PlayerCommandTypeTransferHost:
+		if err := g.game.TransferHost(command.Target); err != nil {
+			g.rejectCommand(command, newCommandError(CommandErrorBadPayload, err.Error()))
+			return
+		}
+		g.gameService.SaveGame(g.game)
+		event = newGameEventHostChange(g.game.ID, command.Target)
+		g.ackCommand(command)
+
+	case PlayerCommandTypeUpdateSettings:
+		s := command.Settings
+		if g.questionBank == nil || g.game.IsPractice() {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, "game settings can not be changed"))
+			return
+		}
+		// private games need an invite code, which is only handed out when a
+		// game is created
+		if s.Private != nil && *s.Private != g.game.Private {
+			g.rejectCommand(command, newCommandError(CommandErrorBadPayload, "a game can not be made public or private once created"))
+			return
+		}
+		if err := g.game.Reconfigure(s.Name, s.GameSettings, g.questionBank); err != nil {
+			code := CommandErrorBadPayload
+			if errors.Is(err, captrivia.ErrGameStarted) {
+				code = CommandErrorInvalidState
+			}
+			g.rejectCommand(command, newCommandError(code, err.Error()))
+			return
+		}
+		g.countdownSec = g.game.CountdownSeconds
+		g.questionSec = g.game.QuestionSeconds
+		g.revealSec = g.game.RevealSeconds
+		if s.StartPolicy.Mode != "" {
+			// a new lobby period starts with the new policy
+			g.game.SetDeadline(time.Time{})
+			g.lobbyTimer = nil
+			if g.game.StartPolicy.HasLobbyTimer() {
+				g.lobbyTimer = g.startLobbyTimer()
+			}
+		}
+		g.gameService.SaveGame(g.game)
+		event = newGameEventSettings(g.game)
+		g.ackCommand(command)
+		g.broadcastLobby(event)
+
+	case PlayerCommandTypePause, PlayerCommandTypeResume:
+		if state := g.game.CurrentState(); state == captrivia.GameStateWaiting || state.Finished() || g.game.IsPractice() {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, "only a running game can be paused or resumed"))
+			return
+		}
+		// RunGame pauses or resumes the game and answers the command
+		select {
+		case g.pauses <- command:
+		default:
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, "the game is busy, try again"))
+		}
+		return
+
+	case PlayerCommandTypeCancel:
+		running := g.game.InProgress()
+		if err := g.ChangeGameState(captrivia.GameStateAborted); err != nil {
+			g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+			return
+		}
+		event = newGameEventCancel(g.game.ID)
+		g.ackCommand(command)
+		g.Broadcast <- event.toBytes()
+
+		// a running game is stopped by RunGame, which reports when it is done
+		if running && !g.game.IsPractice() {
+			close(g.cancelled)
+		} else {
+			done <- true
+		}
+		return
+	}
+	g.Broadcast <- event.toBytes()
+}
+
+// startGame moves the game out of its lobby and runs it, whether the host
+// started it or its start policy did.
+func (g *GameHub) startGame(done chan<- bool) error {
+	// players that didn't pick a team are shared out before the game starts
+	g.game.BalanceTeams()
+	if err := g.ChangeGameState(captrivia.GameStateCountdown); err != nil {
+		return err
+	}
+	event := newGameEventStart(g.game.ID)
+	g.Broadcast <- event.toBytes()
+	if g.game.HasTeams() {
+		teamsEvent := newGameEventTeams(g.game.ID, g.game.TeamScores())
+		g.Broadcast <- teamsEvent.toBytes()
+	}
+
+	go g.RunGame(done)
+	return nil
+}
+
+// startLobbyTimer starts the lobby period of an auto start game, telling the
+// game and the lobby how long it is. A recovered game keeps the time that was
+// left in its lobby.
+func (g *GameHub) startLobbyTimer() <-chan time.Time {
+	remaining := max(time.Until(g.game.Deadline()), 0)
+	if g.game.Deadline().IsZero() {
+		remaining = time.Duration(g.game.StartPolicy.LobbySeconds) * time.Second
+		g.game.SetDeadline(time.Now().Add(remaining))
+		g.gameService.SaveGame(g.game)
+	}
+
+	event := newGameEventLobbyTimer(g.game.ID, g.game.StartPolicy, remaining)
+	g.Broadcast <- event.toBytes()
+	g.broadcastLobby(event)
+
+	return time.After(remaining)
+}
+
+// kickPlayer removes the player from the game at the host's request. The
+// player is sent the kick event before they are removed, then returned to the
+// Hub lobby.
+func (g *GameHub) kickPlayer(player string, event GameEvent) {
+	client := g.playerClient(player)
+	if client == nil {
+		// the player's client is detached or on its way back, free the seat
+		// and let go of a detached client so reconnecting doesn't bring the
+		// player back into the game
+		g.mu.Lock()
+		for c := range g.Clients {
+			if c.name == player {
+				delete(g.Clients, c)
+				c.gameHub.Store(nil)
+			}
+		}
+		g.mu.Unlock()
+		g.game.RemovePlayer(player)
+		g.gameService.SaveGame(g.game)
+		g.broadcastLobby(newGameEventPlayerCount(g.game))
+		return
+	}
+
+	select {
+	case client.Send <- event.toBytes():
+	default:
+	}
+	g.playerLeave(client)
+	client.hub.register <- client
+}
+
 // helper function to add player to Game and generate PlayerEnter + PlayerJoin
 // GameEvents to be broadcast to the game lobby
 func (g *GameHub) playerJoin(client *Client) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	g.game.AddPlayer(client.name)
+	// spectators were turned away from a full game and keep out of the seats.
+	// A player coming back to a game whose seats were all taken while they
+	// were away watches it instead
+	if !client.spectator.Load() && errors.Is(g.game.AddPlayer(client.name), captrivia.ErrGameFull) {
+		client.spectator.Store(true)
+	}
+	if client.spectator.Load() {
+		g.game.AddSpectator(client.name)
+	}
 	g.gameService.SaveGame(g.game)
 
 	enterEvent := newGameEventPlayerEnter(client.name, g.game)
 	client.Send <- enterEvent.toBytes()
 
+	if g.game.CurrentState() == captrivia.GameStateWaiting && g.game.StartPolicy.HasLobbyTimer() {
+		timerEvent := newGameEventLobbyTimer(g.game.ID, g.game.StartPolicy, time.Until(g.game.Deadline()))
+		client.Send <- timerEvent.toBytes()
+	}
+
 	// unregister player from hub broadcasts
 	client.hub.unregister <- client
 
+	if client.spectator.Load() {
+		spectatorEvent := newGameEventSpectatorJoin(g.game.ID, client.name)
+		g.Broadcast <- spectatorEvent.toBytes()
+
+		g.broadcastLobby(newGameEventPlayerCount(g.game))
+		return
+	}
+
 	joinEvent := newGameEventPlayerJoin(g.game.ID, client.name)
 	g.Broadcast <- joinEvent.toBytes()
 
-	playerCountEvent := newGameEventPlayerCount(g.game.ID, g.game.PlayerCount)
-	g.hubBroadcast <- playerCountEvent
+	playerCountEvent := newGameEventPlayerCount(g.game)
+	g.broadcastLobby(playerCountEvent)
 }
 
 // Helper function to remove a player from GameHub + Game, and re-register
@@ -143,28 +479,86 @@ func (g *GameHub) playerLeave(client *Client) {
 		return
 	}
 	delete(g.Clients, client)
+	client.gameHub.Store(nil)
+
+	if client.spectator.Load() {
+		client.spectator.Store(false)
+		g.game.RemoveSpectator(client.name)
+		g.gameService.SaveGame(g.game)
+
+		spectatorEvent := newGameEventSpectatorLeave(g.game.ID, client.name)
+		g.Broadcast <- spectatorEvent.toBytes()
+
+		g.broadcastLobby(newGameEventPlayerCount(g.game))
+		return
+	}
+
+	wasHost := g.game.IsHost(client.name)
 	g.game.RemovePlayer(client.name)
 	g.gameService.SaveGame(g.game)
 
-	playerCountEvent := newGameEventPlayerCount(g.game.ID, g.game.PlayerCount)
-	g.hubBroadcast <- playerCountEvent
+	// nobody is left to play a practice game. The daily challenge was used up
+	// when the game was created, one left before it is over, even before its
+	// first question, counts as played with what was answered
+	if g.game.IsPractice() {
+		if !g.game.CurrentState().Finished() {
+			g.saveDailyResult(client.name)
+		}
+		select {
+		case g.abandoned <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	playerCountEvent := newGameEventPlayerCount(g.game)
+	g.broadcastLobby(playerCountEvent)
 
 	leaveEvent := newGameEventPlayerLeave(g.game.ID, client.name)
 	g.Broadcast <- leaveEvent.toBytes()
+
+	// the host passes to the longest seated player that is left
+	if wasHost && g.game.Host != "" {
+		hostEvent := newGameEventHostChange(g.game.ID, g.game.Host)
+		g.Broadcast <- hostEvent.toBytes()
+	}
 }
 
 // Runs the main trivia game loop. Listens for answers from client and handles
 // the tickers used for countdowns and question durations
 func (g *GameHub) RunGame(done chan<- bool) {
 	countdownEvent := newGameEventCountdown(g.game.ID, g.countdownSec)
-	g.Broadcast <- countdownEvent.toBytes()
 	countdownDuration := time.Duration(g.countdownSec) * time.Second
 	questionDuration := time.Duration(g.questionSec) * time.Second
 
 	countdownTicker := time.NewTicker(countdownDuration)
 	questionTicker := time.NewTicker(questionDuration)
+	var revealTimer <-chan time.Time // fires when the reveal of an answer is over
 
-	g.ChangeGameState(captrivia.GameStateCountdown)
+	if g.game.CurrentState() == captrivia.GameStatePaused {
+		// a game recovered while paused carries on with the time it had left
+		g.game.Resume(time.Now())
+	}
+	if remaining := time.Until(g.game.Deadline()); g.game.State == captrivia.GameStateQuestion && remaining > 0 {
+		// a recovered game was part way through a question, display it again
+		// for the time that was left on it
+		countdownTicker.Stop()
+		questionTicker.Reset(remaining)
+		g.handleResumeQuestion(remaining)
+	} else {
+		// the game was moved to countdown when it was started, a recovered
+		// game that was between questions starts the countdown over
+		questionTicker.Stop()
+		if g.game.State == captrivia.GameStateReveal {
+			// the answer was already revealed, move on to the next question
+			g.game.GoToNextQuestion()
+		}
+		g.Broadcast <- countdownEvent.toBytes()
+		g.game.SetDeadline(time.Now().Add(countdownDuration))
+		if g.game.State != captrivia.GameStateCountdown {
+			g.ChangeGameState(captrivia.GameStateCountdown)
+		}
+	}
 
 	defer questionTicker.Stop()
 	defer countdownTicker.Stop()
@@ -178,60 +572,324 @@ func (g *GameHub) RunGame(done chan<- bool) {
 
 		case <-questionTicker.C: // time expired before a correct answer was provided
 			questionTicker.Stop()
-			g.handleQuestionTimeExpired()
+			if revealTimer = g.handleQuestionEnd(); revealTimer == nil {
+				g.handleNextQuestion()
+				g.Broadcast <- countdownEvent.toBytes()
+				countdownTicker = time.NewTicker(countdownDuration)
+			}
+
+		case <-revealTimer: // players have seen the answer, count down to the next question
+			revealTimer = nil
+			g.handleNextQuestion()
 			g.Broadcast <- countdownEvent.toBytes()
 			countdownTicker = time.NewTicker(countdownDuration)
 
 		case ans := <-g.Answers: // player has answered the question
-			correct := g.game.ValidateAnswer(ans.Index)
+			outcome := g.handleAnswer(ans, questionDuration)
+			if !outcome.Accepted {
+				continue
+			}
 
-			if correct {
+			if outcome.CloseQuestion {
 				questionTicker.Stop()
+				if revealTimer = g.handleQuestionEnd(); revealTimer == nil {
+					g.handleNextQuestion()
+					g.Broadcast <- countdownEvent.toBytes()
+					countdownTicker = time.NewTicker(countdownDuration)
+				}
+			} else {
+				g.gameService.SaveGame(g.game)
+			}
 
-				g.game.IncrementPlayerScore(ans.Player)
-				event := newGameEventPlayerCorrect(g.game.ID, ans.Player, ans.QuestionID)
-				g.Broadcast <- event.toBytes()
-
-				g.game.GoToNextQuestion()
+		case <-g.gameEnded:
+			g.handleGameEnd()
+			done <- true
+			return
 
-				g.Broadcast <- countdownEvent.toBytes()
-				countdownTicker = time.NewTicker(countdownDuration)
+		case command := <-g.pauses: // the host paused or resumed the game
+			if command.Type == PlayerCommandTypePause {
+				if err := g.game.Pause(time.Now()); err != nil {
+					g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+					continue
+				}
+				countdownTicker.Stop()
+				questionTicker.Stop()
+				revealTimer = nil
+				g.pauseChanged(command, newGameEventPause(g.game.ID))
+				continue
+			}
 
-				g.ChangeGameState(captrivia.GameStateCountdown)
-			} else {
-				event := newGameEventPlayerIncorrect(g.game.ID, ans.Player, ans.QuestionID)
-				g.Broadcast <- event.toBytes()
+			state, remaining, err := g.game.Resume(time.Now())
+			if err != nil {
+				g.rejectCommand(command, newCommandError(CommandErrorInvalidState, err.Error()))
+				continue
 			}
+			// tickers can't be reset to fire straight away
+			wait := max(remaining, time.Millisecond)
+			switch state {
+			case captrivia.GameStateCountdown:
+				countdownTicker.Reset(wait)
+			case captrivia.GameStateQuestion:
+				questionTicker.Reset(wait)
+			case captrivia.GameStateReveal:
+				revealTimer = time.After(wait)
+			}
+			g.pauseChanged(command, newGameEventResume(g.game.ID, state, remaining))
 
-		case <-g.gameEnded:
-			gameEndEvent := newGameEventEnd(g.game.ID, g.game.PlayerScores())
-			g.Broadcast <- gameEndEvent.toBytes()
-			g.ChangeGameState(captrivia.GameStateEnded)
+		case <-g.cancelled: // the host cancelled the game
 			done <- true
 			return
 		}
 	}
 }
 
-func (g *GameHub) ChangeGameState(state captrivia.GameState) {
-	g.game.State = state
+// pauseChanged saves a game that was paused or resumed, answers the host and
+// lets the game and the lobby know.
+func (g *GameHub) pauseChanged(command GameLobbyCommand, event GameEvent) {
 	g.gameService.SaveGame(g.game)
-	g.hubBroadcast <- newGameEventStateChange(g.game.ID, g.game.State)
+	g.ackCommand(command)
+	g.Broadcast <- event.toBytes()
+	g.broadcastLobby(newGameEventStateChange(g.game.ID, g.game.CurrentState()))
+}
+
+// handleAnswer scores a player's answer, broadcasting whether it was correct.
+// Only the player hears about an answer that was rejected.
+func (g *GameHub) handleAnswer(ans GameAnswer, questionDuration time.Duration) captrivia.AnswerOutcome {
+	record, outcome := g.game.AnswerQuestion(ans.Player, ans.QuestionID, ans.Index, ans.ReceivedAt, questionDuration)
+	if !outcome.Accepted {
+		if client := g.playerClient(ans.Player); client != nil {
+			rejectedEvent := newGameEventAnswerRejected(g.game.ID, ans.QuestionID, outcome.Rejection)
+			client.Send <- rejectedEvent.toBytes()
+		}
+		return outcome
+	}
+
+	var event GameEvent
+	if record.Correct {
+		event = newGameEventPlayerCorrect(g.game.ID, ans.Player, ans.QuestionID, outcome.Points)
+	} else {
+		event = newGameEventPlayerIncorrect(g.game.ID, ans.Player, ans.QuestionID, outcome.Points)
+	}
+	g.Broadcast <- event.toBytes()
+	return outcome
+}
+
+// handleGameEnd broadcasts the final scores once the last question is over
+// and records the game's answers.
+func (g *GameHub) handleGameEnd() {
+	gameEndEvent := newGameEventEnd(g.game)
+	g.Broadcast <- gameEndEvent.toBytes()
+	if err := g.gameService.SaveAnswerLog(g.game.ID, g.game.Answers()); err != nil {
+		log.Printf("error saving answer log for gameID=%s. Err: %s", g.game.ID, err)
+	}
+	if g.game.UpdatesLeaderboard() {
+		if err := g.gameService.UpdatePlayerStats(g.game.PlayerStats()); err != nil {
+			log.Printf("error updating player stats for gameID=%s. Err: %s", g.game.ID, err)
+		}
+	}
+	g.saveDailyResult(g.game.Host)
+	g.ChangeGameState(captrivia.GameStateEnded)
+}
+
+// saveDailyResult records the player's result if the game is a daily
+// challenge.
+func (g *GameHub) saveDailyResult(player string) {
+	if g.game.Daily == "" {
+		return
+	}
+	if err := g.gameService.SaveDailyResult(g.game.DailyResult(player, time.Now())); err != nil {
+		log.Printf("error saving daily result for gameID=%s. Err: %s", g.game.ID, err)
+	}
+}
+
+// handlePracticeAnswer tells the player of a practice game straight away
+// whether their answer was right and what the answer was. The game waits on
+// the answer until the player moves on with next_question.
+func (g *GameHub) handlePracticeAnswer(ans GameAnswer) {
+	if outcome := g.handleAnswer(ans, 0); !outcome.Accepted {
+		return
+	}
+	revealEvent := newGameEventQuestionReveal(g.game.ID, g.game.Reveal(), 0)
+	g.ChangeGameState(captrivia.GameStateReveal)
+	g.Broadcast <- revealEvent.toBytes()
+}
+
+// nextPracticeQuestion shows the player of a practice game their next
+// question, whether or not they answered the last one. It reports whether the
+// game is over because the player moved on from the last question.
+func (g *GameHub) nextPracticeQuestion() (bool, error) {
+	if !g.game.IsPractice() {
+		return false, errors.New("only practice games are moved on by their player")
+	}
+	switch state := g.game.State; state {
+	case captrivia.GameStateWaiting:
+	case captrivia.GameStateQuestion, captrivia.GameStateReveal:
+		last := g.game.IsLastQuestion()
+		g.game.GoToNextQuestion()
+		if last {
+			// the end of the game is signalled for RunGame, which practice
+			// games don't run
+			<-g.gameEnded
+			return true, nil
+		}
+	default:
+		return false, fmt.Errorf("can not move on while the game is in state %s", state)
+	}
+
+	// practice games have no countdown, the game passes straight through it
+	if err := g.ChangeGameState(captrivia.GameStateCountdown); err != nil {
+		return false, err
+	}
+	g.handleDisplayQuestion()
+	return false, nil
+}
+
+// ChangeGameState moves the game to the state, saving it and broadcasting the
+// change to the Hub. Transitions the game does not allow are rejected.
+func (g *GameHub) ChangeGameState(state captrivia.GameState) error {
+	if err := g.game.TransitionTo(state); err != nil {
+		log.Printf("error changing state of gameID=%s: %s", g.game.ID, err)
+		return err
+	}
+	g.gameService.SaveGame(g.game)
+	g.broadcastLobby(newGameEventStateChange(g.game.ID, g.game.State))
+	return nil
+}
+
+// ackCommand sends a command_ack for the lobby command to the player that
+// issued it.
+func (g *GameHub) ackCommand(command GameLobbyCommand) {
+	if client := g.playerClient(command.Player); client != nil {
+		cmd := PlayerCommand{Nonce: command.Nonce, Type: command.Type}
+		client.ack(cmd, g.game.ID, g.game.CurrentState())
+	}
+}
+
+// rejectCommand sends a command_error for the lobby command to the player
+// that issued it.
+func (g *GameHub) rejectCommand(command GameLobbyCommand, err *CommandError) {
+	if client := g.playerClient(command.Player); client != nil {
+		cmd := PlayerCommand{Nonce: command.Nonce, Type: command.Type}
+		client.sendCommandError(cmd, err)
+	}
+}
+
+// playerClient returns the attached client of the player, or nil if the
+// player has no client attached to the GameHub.
+func (g *GameHub) playerClient(player string) *Client {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for client := range g.Clients {
+		if client.name == player && !client.detached.Load() {
+			return client
+		}
+	}
+	return nil
 }
 
 // helper function used to get current game question, create GameEvent to display
-// question to users, and emit game state change to Hub
+// question to users, and emit game state change to Hub. The display time is
+// recorded so answer latency can be measured against it.
 func (g *GameHub) handleDisplayQuestion() {
 	q := g.game.CurrentQuestion()
 	questionEvent := newGameEventQuestion(g.game.ID, q, g.questionSec)
+	now := time.Now()
+	g.game.QuestionDisplayed(now)
+	g.game.SetDeadline(now.Add(time.Duration(g.questionSec) * time.Second))
 	g.Broadcast <- questionEvent.toBytes()
 
 	g.ChangeGameState(captrivia.GameStateQuestion)
 }
 
-// helper function used when a question has reached its duration and the correct
-// answer was not provided.
-func (g *GameHub) handleQuestionTimeExpired() {
+// helper function used to display the current question of a recovered game
+// again with the time that was remaining on it
+func (g *GameHub) handleResumeQuestion(remaining time.Duration) {
+	q := g.game.CurrentQuestion()
+	questionEvent := newGameEventQuestion(g.game.ID, q, int(remaining.Round(time.Second).Seconds()))
+	g.Broadcast <- questionEvent.toBytes()
+}
+
+// helper function used to move on to the countdown before the next question
+// once a question has ended, and its answer has been revealed.
+func (g *GameHub) handleNextQuestion() {
 	g.game.GoToNextQuestion()
+	g.game.SetDeadline(time.Now().Add(time.Duration(g.countdownSec) * time.Second))
 	g.ChangeGameState(captrivia.GameStateCountdown)
 }
+
+// handleQuestionEnd takes a life from the survival game players that missed
+// the question that just ended before its answer is revealed, see
+// handleRevealAnswer for the returned channel.
+func (g *GameHub) handleQuestionEnd() <-chan time.Time {
+	if g.game.Mode == captrivia.GameModeSurvival {
+		host := g.game.Host
+		lost, eliminated := g.game.TakeLives()
+		livesEvent := newGameEventLives(g.game.ID, g.game.LivesLeft(), lost)
+		g.Broadcast <- livesEvent.toBytes()
+		for _, player := range eliminated {
+			g.playerEliminated(player)
+		}
+		if len(eliminated) > 0 {
+			g.gameService.SaveGame(g.game)
+			g.broadcastLobby(newGameEventPlayerCount(g.game))
+		}
+		if g.game.Host != host {
+			hostEvent := newGameEventHostChange(g.game.ID, g.game.Host)
+			g.Broadcast <- hostEvent.toBytes()
+		}
+	}
+	return g.handleRevealAnswer()
+}
+
+// playerEliminated turns the eliminated player's client into a spectator and
+// lets the game know the player is out and watching.
+func (g *GameHub) playerEliminated(player string) {
+	g.mu.Lock()
+	for client := range g.Clients {
+		if client.name == player {
+			client.spectator.Store(true)
+		}
+	}
+	g.mu.Unlock()
+
+	eliminatedEvent := newGameEventPlayerEliminated(g.game.ID, player)
+	g.Broadcast <- eliminatedEvent.toBytes()
+	spectatorEvent := newGameEventSpectatorJoin(g.game.ID, player)
+	g.Broadcast <- spectatorEvent.toBytes()
+}
+
+// handleRevealAnswer shows players the answer to the question that just
+// ended. The returned channel fires when the reveal is over, it is nil when
+// the game has no reveal phase and goes straight on to the next countdown
+// without showing the answer.
+func (g *GameHub) handleRevealAnswer() <-chan time.Time {
+	if g.revealSec <= 0 {
+		return nil
+	}
+
+	// the question is closed to answers before players see the answer
+	revealEvent := newGameEventQuestionReveal(g.game.ID, g.game.Reveal(), g.revealSec)
+	revealDuration := time.Duration(g.revealSec) * time.Second
+	g.game.SetDeadline(time.Now().Add(revealDuration))
+	g.ChangeGameState(captrivia.GameStateReveal)
+	g.Broadcast <- revealEvent.toBytes()
+	return time.After(revealDuration)
+}
+
+// awaitingPlayer reports whether the player has a seat in the game but no
+// client attached to the GameHub, as is the case for players of a game
+// recovered after a restart.
+func (g *GameHub) awaitingPlayer(player string) bool {
+	if g.game.State.Finished() || !g.game.HasPlayer(player) {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for client := range g.Clients {
+		if client.name == player {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,13 @@ package server_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,7 +50,7 @@ func TestGameHubRegisterClient(t *testing.T) {
 	countdownSec := 5
 	questionSec := 10
 
-	hub := server.NewHub(gameService, 5, 5)
+	hub := server.NewHub(gameService, testQuestionBank, 5, 5)
 	gameHub := server.NewGameHub(game, gameService, hubBroadcast, countdownSec, questionSec)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -87,7 +94,7 @@ func TestGameHubBroadcast(t *testing.T) {
 	countdownSec := 5
 	questionSec := 10
 
-	hub := server.NewHub(gameService, 5, 5)
+	hub := server.NewHub(gameService, testQuestionBank, 5, 5)
 	gameHub := server.NewGameHub(game, gameService, hubBroadcast, countdownSec, questionSec)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -137,7 +144,7 @@ func TestGameHubUnregisterFailure(t *testing.T) {
 	countdownSec := 5
 	questionSec := 10
 
-	hub := server.NewHub(gameService, 5, 5)
+	hub := server.NewHub(gameService, testQuestionBank, 5, 5)
 	gameHub := server.NewGameHub(game, gameService, hubBroadcast, countdownSec, questionSec)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -152,3 +159,1239 @@ func TestGameHubUnregisterFailure(t *testing.T) {
 	time.Sleep(1 * time.Second)
 	assert.Equal(t, 0, game.PlayerCount)
 }
+
+func TestHubRestoreGames(t *testing.T) {
+	questions := []captrivia.Question{
+		{ID: "1", QuestionText: "question 1", Options: []string{"a", "b"}, CorrectIndex: 0},
+		{ID: "2", QuestionText: "question 2", Options: []string{"a", "b"}, CorrectIndex: 1},
+	}
+	inProgress := captrivia.GameSnapshot{
+		ID:                   uuid.New(),
+		Name:                 "in progress",
+		QuestionCount:        2,
+		State:                captrivia.GameStateQuestion,
+		Questions:            questions,
+		CurrentQuestionIndex: 1,
+		Deadline:             time.Now().Add(10 * time.Second),
+		PlayersReady:         map[string]bool{"test_client": true},
+		Scores:               map[string]int{"test_client": 1},
+	}
+	ended := captrivia.GameSnapshot{
+		ID:    uuid.New(),
+		Name:  "ended",
+		State: captrivia.GameStateEnded,
+	}
+	gameService := MockGameService{snapshots: []captrivia.GameSnapshot{inProgress, ended}}
+
+	hub := server.NewHub(gameService, testQuestionBank, 5, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := hub.RestoreGames(ctx)
+	assert.NoError(t, err)
+
+	gameHub, err := hub.GetGameHub(inProgress.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, inProgress.ID, gameHub.ID)
+
+	_, err = hub.GetGameHub(ended.ID)
+	assert.Error(t, err)
+
+	// players with a seat can rejoin the recovered game
+	client := server.NewClient("test_client", hub)
+	client.Conn = &MockWebSocketConn{}
+	gameHub.Register <- client
+
+	// the resumed question may be broadcast before the player enters
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case message := <-client.Send:
+			var event server.GameEvent
+			json.Unmarshal(message, &event)
+			if event.Type != server.GameEventTypePlayerEnter {
+				continue
+			}
+			assert.Equal(t, inProgress.ID, event.ID)
+			return
+		case <-timeout:
+			t.Fatal("player was not put back in the recovered game")
+		}
+	}
+}
+
+// joinGame connects a player to the server and joins them to the game,
+// returning once they have entered it.
+func joinGame(t *testing.T, s *httptest.Server, name string, gameID uuid.UUID) *websocket.Conn {
+	ws, _, err := dialConnect(s, "name="+url.QueryEscape(name))
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   name + "-join",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	readUntil(t, ws, server.GameEventTypePlayerJoin)
+	return ws
+}
+
+func TestGameHubHostCommands(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	guest := joinGame(t, s, "guest", gh.ID)
+	defer guest.Close()
+
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+
+	lobbyCommand := func(nonce string, commandType server.PlayerCommandType) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+			Type:    commandType,
+		})
+	}
+	targetCommand := func(nonce string, commandType server.PlayerCommandType, player string) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandTarget{GameID: gh.ID, Player: player}),
+			Type:    commandType,
+		})
+	}
+
+	// only the host can start the game or kick players
+	guest.WriteMessage(websocket.TextMessage, lobbyCommand("1", server.PlayerCommandTypeStart))
+	assert.Equal(t, server.CommandErrorNotHost, readCommandError(t, guest).Code)
+	guest.WriteMessage(websocket.TextMessage, targetCommand("2", server.PlayerCommandTypeKick, "host"))
+	assert.Equal(t, server.CommandErrorNotHost, readCommandError(t, guest).Code)
+
+	host.WriteMessage(websocket.TextMessage, targetCommand("3", server.PlayerCommandTypeKick, "host"))
+	assert.Equal(t, server.CommandErrorBadPayload, readCommandError(t, host).Code)
+	host.WriteMessage(websocket.TextMessage, targetCommand("4", server.PlayerCommandTypeTransferHost, "nobody"))
+	assert.Equal(t, server.CommandErrorBadPayload, readCommandError(t, host).Code)
+
+	host.WriteMessage(websocket.TextMessage, targetCommand("5", server.PlayerCommandTypeTransferHost, "guest"))
+	var hostChange struct {
+		Payload server.GameEventPlayerLobbyAction `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, guest, server.GameEventTypeHostChange), &hostChange)
+	assert.Equal(t, "guest", hostChange.Payload.Player)
+
+	// the new host kicks the old one, who is sent back to the lobby
+	guest.WriteMessage(websocket.TextMessage, targetCommand("6", server.PlayerCommandTypeKick, "host"))
+	var kick struct {
+		Payload server.GameEventPlayerLobbyAction `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypePlayerKick), &kick)
+	assert.Equal(t, "host", kick.Payload.Player)
+	readUntil(t, guest, server.GameEventTypePlayerLeave)
+
+	host.WriteMessage(websocket.TextMessage, lobbyCommand("7", server.PlayerCommandTypeJoin))
+	json.Unmarshal(readUntil(t, host, server.GameEventTypePlayerEnter), &enter)
+	assert.Equal(t, "guest", enter.Payload.Host)
+	assert.ElementsMatch(t, []string{"host", "guest"}, enter.Payload.Players)
+}
+
+func TestGameHubKickDetachedPlayer(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	guest, _, err := dialConnect(s, "name=guest")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	session := readSession(readUntil(t, guest, server.PlayerEventTypeSession))
+	guest.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "guest-join",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	readUntil(t, guest, server.GameEventTypePlayerJoin)
+	guest.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	// the guest is kicked while they are disconnected
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerCommandTarget{GameID: gh.ID, Player: "guest"}),
+		Type:    server.PlayerCommandTypeKick,
+	}))
+	readCommandAck(t, host)
+
+	// reconnecting brings them back to the lobby rather than the game
+	guest, _, err = dialConnect(s, "token="+session.Payload.Token)
+	if err != nil {
+		t.Fatalf("error reconnecting websocket: %s", err)
+	}
+	defer guest.Close()
+	readUntil(t, guest, server.PlayerEventTypeSession)
+	guest.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "2",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeReady,
+	}))
+	assert.Equal(t, server.CommandErrorNotPlayer, readCommandError(t, guest).Code)
+}
+
+func TestGameHubDefaultQuestionCount(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypePlayerEnter), &enter)
+	assert.Equal(t, captrivia.DefaultQuestionCount, enter.Payload.QuestionCount)
+}
+
+func TestGameHubHostSettingsAndCancel(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+
+	settings := func(nonce string, create server.PlayerCommandCreate) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandSettings{GameID: gh.ID, PlayerCommandCreate: create}),
+			Type:    server.PlayerCommandTypeUpdateSettings,
+		})
+	}
+
+	host.WriteMessage(websocket.TextMessage, settings("1", server.PlayerCommandCreate{GameSettings: captrivia.GameSettings{ScoringMode: "unknown"}}))
+	assert.Equal(t, server.CommandErrorBadPayload, readCommandError(t, host).Code)
+
+	host.WriteMessage(websocket.TextMessage, settings("2", server.PlayerCommandCreate{
+		Name:         "renamed",
+		GameSettings: captrivia.GameSettings{QuestionCount: 2, QuestionSeconds: 10, ScoringMode: captrivia.ScoringModeAllCorrect},
+	}))
+	var event struct {
+		Payload server.GameEventSettings `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeSettings), &event)
+	public := false
+	assert.Equal(t, server.GameEventSettings{
+		Name: "renamed",
+		GameSettings: captrivia.GameSettings{
+			QuestionCount:    2,
+			Mode:             captrivia.GameModeClassic,
+			QuestionSeconds:  10,
+			CountdownSeconds: 3,
+			RevealSeconds:    3,
+			ScoringMode:      captrivia.ScoringModeAllCorrect,
+			StartPolicy:      captrivia.StartPolicy{Mode: captrivia.StartPolicyManual},
+			Private:          &public,
+		},
+	}, event.Payload)
+
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "3",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeCancel,
+	}))
+	readUntil(t, host, server.GameEventTypeCancel)
+
+	// the cancelled game is forgotten and can't be started
+	assert.Eventually(t, func() bool {
+		_, err := hub.GetGameHub(gh.ID)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "4",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+	assert.Equal(t, server.CommandErrorGameNotFound, readCommandError(t, host).Code)
+}
+
+func TestGameHubStartPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy captrivia.StartPolicy
+	}{
+		{"all ready", captrivia.StartPolicy{Mode: captrivia.StartPolicyAllReady}},
+		{"min ready", captrivia.StartPolicy{Mode: captrivia.StartPolicyMinReady, MinReady: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go hub.Run(ctx)
+
+			gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, StartPolicy: tt.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			go gh.Run(ctx)
+
+			s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+			defer s.Close()
+
+			host := joinGame(t, s, "host", gh.ID)
+			defer host.Close()
+
+			lobbyCommand := func(nonce string, commandType server.PlayerCommandType) []byte {
+				return toBytes(server.PlayerCommand{
+					Nonce:   nonce,
+					Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+					Type:    commandType,
+				})
+			}
+
+			// the game can't start until the host is ready
+			host.WriteMessage(websocket.TextMessage, lobbyCommand("1", server.PlayerCommandTypeStart))
+			assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, host).Code)
+
+			host.WriteMessage(websocket.TextMessage, lobbyCommand("2", server.PlayerCommandTypeReady))
+			readUntil(t, host, server.GameEventTypePlayerReady)
+			host.WriteMessage(websocket.TextMessage, lobbyCommand("3", server.PlayerCommandTypeStart))
+			readUntil(t, host, server.GameEventTypeStart)
+		})
+	}
+}
+
+func TestGameHubAutoStart(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	// every player being ready starts the game
+	auto := captrivia.StartPolicy{Mode: captrivia.StartPolicyAuto}
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, StartPolicy: auto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	player := joinGame(t, s, "ready player", gh.ID)
+	defer player.Close()
+	player.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeReady,
+	}))
+	readUntil(t, player, server.GameEventTypeStart)
+
+	// the lobby timer running out starts the game whether or not anyone is ready
+	auto.LobbySeconds = 1
+	gh, err = hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, StartPolicy: auto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	player, _, err = dialConnect(s, "name=waiting+player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer player.Close()
+	player.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "2",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+
+	// players entering the lobby are told how long is left on the timer
+	var timer struct {
+		Payload server.GameEventLobbyTimer `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, player, server.GameEventTypeLobbyTimer), &timer)
+	assert.Equal(t, auto, timer.Payload.StartPolicy)
+	assert.LessOrEqual(t, timer.Payload.Seconds, 1)
+	readUntil(t, player, server.GameEventTypeStart)
+}
+
+func TestGameHubPasswordJoin(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	settings := captrivia.GameSettings{QuestionCount: questionCount, Password: "secret"}
+	gh, err := hub.NewGameHub(gameName, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+
+	join := func(nonce string, password string) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID, Password: password}),
+			Type:    server.PlayerCommandTypeJoin,
+		})
+	}
+
+	ws.WriteMessage(websocket.TextMessage, join("1", "guess"))
+	assert.Equal(t, server.CommandErrorWrongPassword, readCommandError(t, ws).Code)
+
+	ws.WriteMessage(websocket.TextMessage, join("2", "secret"))
+	readUntil(t, ws, server.GameEventTypePlayerEnter)
+}
+
+func TestGameHubPrivateJoin(t *testing.T) {
+	hub := server.NewHub(MockGameService{invites: &sync.Map{}}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	private := true
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, Private: &private})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+	assert.Len(t, gh.InviteCode(), captrivia.InviteCodeLength)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=player")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+
+	// the game can't be joined with its ID alone
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	assert.Equal(t, server.CommandErrorGameNotFound, readCommandError(t, ws).Code)
+
+	// nor by readying up in it first, players without a seat can't issue
+	// lobby commands
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "ready",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeReady,
+	}))
+	assert.Equal(t, server.CommandErrorNotPlayer, readCommandError(t, ws).Code)
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "rejoin",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	assert.Equal(t, server.CommandErrorGameNotFound, readCommandError(t, ws).Code)
+
+	joinByCode := func(nonce string, code string) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandJoinByCode{Code: code}),
+			Type:    server.PlayerCommandTypeJoinByCode,
+		})
+	}
+
+	ws.WriteMessage(websocket.TextMessage, joinByCode("2", "NOSUCH"))
+	assert.Equal(t, server.CommandErrorGameNotFound, readCommandError(t, ws).Code)
+
+	ws.WriteMessage(websocket.TextMessage, joinByCode("3", strings.ToLower(gh.InviteCode())))
+	ack := readCommandAck(t, ws)
+	assert.Equal(t, gh.ID, ack.GameID)
+
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypePlayerEnter), &enter)
+	assert.Equal(t, gh.InviteCode(), enter.Payload.InviteCode)
+}
+
+func TestGameHubFullGame(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, MaxPlayers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+
+	ws, _, err := dialConnect(s, "name=late")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	assert.Equal(t, server.CommandErrorGameFull, readCommandError(t, ws).Code)
+
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "2",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID, SpectateIfFull: true}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypePlayerEnter), &enter)
+	assert.True(t, enter.Payload.Spectator)
+	assert.Equal(t, []string{"late"}, enter.Payload.Spectators)
+	assert.Equal(t, []string{"host"}, enter.Payload.Players)
+	readUntil(t, host, server.GameEventTypeSpectatorJoin)
+
+	// spectators can't ready up
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "3",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeReady,
+	}))
+	assert.Equal(t, server.CommandErrorNotPlayer, readCommandError(t, ws).Code)
+}
+
+func TestGameHubSpectate(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+	readUntil(t, host, server.GameEventTypeStart)
+
+	lobby, _, err := dialConnect(s, "name=lobby")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer lobby.Close()
+
+	ws, _, err := dialConnect(s, "name=late")
+	if err != nil {
+		t.Fatalf("error dialing websocket: %s", err)
+	}
+	defer ws.Close()
+
+	// late joiners can't take a seat in a running game
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, ws).Code)
+
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "2",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeSpectate,
+	}))
+	assert.Equal(t, "2", readCommandAck(t, ws).Nonce)
+
+	var enter struct {
+		Payload server.GameEventPlayerEnter `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypePlayerEnter), &enter)
+	assert.True(t, enter.Payload.Spectator)
+	assert.Equal(t, []string{"host"}, enter.Payload.Players)
+	readUntil(t, host, server.GameEventTypeSpectatorJoin)
+
+	var count struct {
+		Payload server.GameEventPlayerCount `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, lobby, server.GameEventTypePlayerCount), &count)
+	assert.Equal(t, server.GameEventPlayerCount{PlayerCount: 1, SpectatorCount: 1}, count.Payload)
+
+	// spectators see the questions but can't answer them
+	var question struct {
+		Payload server.GameEventQuestion `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestion), &question)
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "3",
+		Payload: Raw(server.PlayerCommandAnswer{GameID: gh.ID, QuestionID: question.Payload.ID}),
+		Type:    server.PlayerCommandTypeAnswer,
+	}))
+	assert.Equal(t, server.CommandErrorNotPlayer, readCommandError(t, ws).Code)
+}
+
+func TestGameHubAnswerRejected(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws := joinGame(t, s, "player", gh.ID)
+	defer ws.Close()
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+
+	var question struct {
+		Payload server.GameEventQuestion `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestion), &question)
+
+	answer := func(nonce string, questionID string) {
+		ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandAnswer{GameID: gh.ID, Index: -1, QuestionID: questionID}),
+			Type:    server.PlayerCommandTypeAnswer,
+		}))
+	}
+	var rejected struct {
+		Payload server.GameEventAnswerRejected `json:"payload"`
+	}
+
+	answer("1", "previous question")
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeAnswerRejected), &rejected)
+	assert.Equal(t, server.GameEventAnswerRejected{QuestionID: "previous question", Reason: captrivia.AnswerRejectedStale}, rejected.Payload)
+
+	answer("2", question.Payload.ID)
+	readUntil(t, ws, server.GameEventTypePlayerIncorrect)
+
+	answer("3", question.Payload.ID)
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeAnswerRejected), &rejected)
+	assert.Equal(t, captrivia.AnswerRejectedDuplicate, rejected.Payload.Reason)
+}
+
+func TestGameHubQuestionReveal(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, RevealSeconds: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws := joinGame(t, s, "player", gh.ID)
+	defer ws.Close()
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+
+	var question struct {
+		Payload server.GameEventQuestion `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestion), &question)
+
+	var correct captrivia.Question
+	for _, q := range testQuestionBank.Questions() {
+		if q.ID == question.Payload.ID {
+			correct = q
+		}
+	}
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "1",
+		Payload: Raw(server.PlayerCommandAnswer{GameID: gh.ID, Index: correct.CorrectIndex, QuestionID: correct.ID}),
+		Type:    server.PlayerCommandTypeAnswer,
+	}))
+
+	var reveal struct {
+		Payload server.GameEventQuestionReveal `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestionReveal), &reveal)
+	assert.Equal(t, correct.ID, reveal.Payload.QuestionID)
+	assert.Equal(t, correct.CorrectIndex, reveal.Payload.CorrectIndex)
+	assert.Equal(t, []string{"player"}, reveal.Payload.CorrectPlayers)
+	assert.Equal(t, 1, reveal.Payload.PickCounts[correct.CorrectIndex])
+	assert.Equal(t, 1, reveal.Payload.Seconds)
+
+	// answers aren't taken while the answer is shown
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "2",
+		Payload: Raw(server.PlayerCommandAnswer{GameID: gh.ID, Index: correct.CorrectIndex, QuestionID: correct.ID}),
+		Type:    server.PlayerCommandTypeAnswer,
+	}))
+	var rejected struct {
+		Payload server.GameEventAnswerRejected `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeAnswerRejected), &rejected)
+	assert.Equal(t, captrivia.AnswerRejectedNotOpen, rejected.Payload.Reason)
+
+	readUntil(t, ws, server.GameEventTypeCountdown)
+}
+
+func TestGameHubNoReveal(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	hub.RevealSec = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	// the server's default reveal is not applied to a game that turns it off
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, RevealSeconds: captrivia.NoReveal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws := joinGame(t, s, "player", gh.ID)
+	defer ws.Close()
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+	readUntil(t, ws, server.GameEventTypeQuestion)
+
+	// the game counts down to the next question as soon as the question
+	// times out, without revealing its answer
+	asked := time.Now()
+	ws.SetReadDeadline(asked.Add(3 * time.Second))
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("did not receive countdown: %s", err)
+		}
+		var event struct {
+			Type server.GameEventType `json:"type"`
+		}
+		json.Unmarshal(msg, &event)
+		assert.NotEqual(t, server.GameEventTypeQuestionReveal, event.Type)
+		if event.Type == server.GameEventTypeCountdown {
+			break
+		}
+	}
+	assert.Less(t, time.Since(asked), 2*time.Second)
+}
+
+func TestGameHubPause(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	player := joinGame(t, s, "player", gh.ID)
+	defer player.Close()
+
+	command := func(ws *websocket.Conn, nonce string, commandType server.PlayerCommandType) {
+		ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+			Type:    commandType,
+		}))
+	}
+
+	// a game that hasn't started can't be paused
+	command(host, "early", server.PlayerCommandTypePause)
+	assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, host).Code)
+
+	command(host, "start", server.PlayerCommandTypeStart)
+	readCommandAck(t, host)
+	readUntil(t, player, server.GameEventTypeCountdown)
+
+	command(player, "pause", server.PlayerCommandTypePause)
+	assert.Equal(t, server.CommandErrorNotHost, readCommandError(t, player).Code)
+	command(host, "pause", server.PlayerCommandTypePause)
+	assert.Equal(t, captrivia.GameStatePaused, readCommandAck(t, host).State)
+	readUntil(t, player, server.GameEventTypePause)
+
+	// the countdown doesn't run out while the game is paused
+	player.SetReadDeadline(time.Now().Add(1500 * time.Millisecond))
+	for {
+		_, msg, err := player.ReadMessage()
+		if err != nil {
+			break
+		}
+		assert.NotContains(t, string(msg), server.GameEventTypeQuestion, "question shown while paused")
+	}
+	// the timed out read leaves the connection unusable
+	player.Close()
+
+	command(host, "resume", server.PlayerCommandTypeResume)
+	readCommandAck(t, host)
+	var resume struct {
+		Payload server.GameEventResume `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeResume), &resume)
+	assert.Equal(t, captrivia.GameStateCountdown, resume.Payload.State)
+	readUntil(t, host, server.GameEventTypeQuestion)
+
+	command(host, "resume again", server.PlayerCommandTypeResume)
+	assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, host).Code)
+}
+
+func TestGameHubTeams(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, Teams: []string{"red", "blue"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	player := joinGame(t, s, "player", gh.ID)
+	defer player.Close()
+
+	pickTeam := func(nonce string, team string) []byte {
+		return toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerCommandTeam{GameID: gh.ID, Team: team}),
+			Type:    server.PlayerCommandTypePickTeam,
+		})
+	}
+
+	player.WriteMessage(websocket.TextMessage, pickTeam("1", "green"))
+	assert.Equal(t, server.CommandErrorBadPayload, readCommandError(t, player).Code)
+
+	player.WriteMessage(websocket.TextMessage, pickTeam("2", "red"))
+	var pick struct {
+		Payload server.GameEventTeamPick `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeTeamPick), &pick)
+	assert.Equal(t, server.GameEventTeamPick{Player: "player", Team: "red"}, pick.Payload)
+
+	// the host didn't pick a team so is put on the smaller one
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+	var teams struct {
+		Payload server.GameEventTeams `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, player, server.GameEventTypeTeams), &teams)
+	assert.ElementsMatch(t, []captrivia.TeamScore{
+		{Name: "red", Players: []string{"player"}},
+		{Name: "blue", Players: []string{"host"}},
+	}, teams.Payload.Teams)
+
+	player.WriteMessage(websocket.TextMessage, pickTeam("3", "blue"))
+	assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, player).Code)
+}
+
+func TestGameHubSurvival(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, RevealSeconds: 1, Mode: captrivia.GameModeSurvival, Lives: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go gh.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	host := joinGame(t, s, "host", gh.ID)
+	defer host.Close()
+	player := joinGame(t, s, "player", gh.ID)
+	defer player.Close()
+
+	host.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "start",
+		Payload: Raw(server.PlayerLobbyCommand{GameID: gh.ID}),
+		Type:    server.PlayerCommandTypeStart,
+	}))
+
+	var question struct {
+		Payload server.GameEventQuestion `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeQuestion), &question)
+
+	var correct captrivia.Question
+	for _, q := range testQuestionBank.Questions() {
+		if q.ID == question.Payload.ID {
+			correct = q
+		}
+	}
+	answer := func(ws *websocket.Conn, index int) {
+		ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+			Nonce:   "answer",
+			Payload: Raw(server.PlayerCommandAnswer{GameID: gh.ID, Index: index, QuestionID: correct.ID}),
+			Type:    server.PlayerCommandTypeAnswer,
+		}))
+	}
+
+	// the question stays open after a correct answer until everyone has answered
+	answer(host, correct.CorrectIndex)
+	readUntil(t, host, server.GameEventTypePlayerCorrect)
+	answer(player, (correct.CorrectIndex+1)%len(correct.Options))
+
+	var lives struct {
+		Payload server.GameEventLives `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeLives), &lives)
+	assert.Equal(t, map[string]int{"host": 1, "player": 0}, lives.Payload.Lives)
+	assert.Equal(t, []string{"player"}, lives.Payload.LostLife)
+
+	var eliminated struct {
+		Payload server.GameEventPlayerLobbyAction `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypePlayerEliminated), &eliminated)
+	assert.Equal(t, "player", eliminated.Payload.Player)
+
+	// the eliminated player keeps watching from the spectators
+	var spectator struct {
+		Payload server.GameEventPlayerLobbyAction `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeSpectatorJoin), &spectator)
+	assert.Equal(t, "player", spectator.Payload.Player)
+
+	// the host is the last player standing so the game ends early
+	var end struct {
+		Payload server.GameEventEnd `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, host, server.GameEventTypeEnd), &end)
+	assert.Equal(t, []string{"host"}, end.Payload.Survivors)
+}
+
+func TestGameHubPractice(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=solo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "practice",
+		Payload: Raw(server.PlayerCommandPractice{GameSettings: captrivia.GameSettings{QuestionCount: 2}}),
+		Type:    server.PlayerCommandTypePractice,
+	}))
+	ack := readCommandAck(t, ws)
+	assert.Equal(t, captrivia.GameStateWaiting, ack.State)
+	gameID := ack.GameID
+
+	// practice games can't be joined by anyone else
+	other, _, err := dialConnect(s, "name=other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	other.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "join",
+		Payload: Raw(server.PlayerCommandJoin{GameID: gameID}),
+		Type:    server.PlayerCommandTypeJoin,
+	}))
+	assert.Equal(t, server.CommandErrorGameNotFound, readCommandError(t, other).Code)
+
+	command := func(nonce string, commandType server.PlayerCommandType) {
+		ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+			Nonce:   nonce,
+			Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+			Type:    commandType,
+		}))
+	}
+	command("start", server.PlayerCommandTypeStart)
+	assert.Equal(t, server.CommandErrorInvalidState, readCommandError(t, ws).Code)
+
+	// questions are untimed and shown when the player asks for them
+	command("next-1", server.PlayerCommandTypeNextQuestion)
+	var question struct {
+		Payload server.GameEventQuestion `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestion), &question)
+	assert.Equal(t, 0, question.Payload.Seconds)
+
+	var correct captrivia.Question
+	for _, q := range testQuestionBank.Questions() {
+		if q.ID == question.Payload.ID {
+			correct = q
+		}
+	}
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+		Nonce:   "answer",
+		Payload: Raw(server.PlayerCommandAnswer{GameID: gameID, Index: correct.CorrectIndex, QuestionID: correct.ID}),
+		Type:    server.PlayerCommandTypeAnswer,
+	}))
+	readUntil(t, ws, server.GameEventTypePlayerCorrect)
+	var reveal struct {
+		Payload server.GameEventQuestionReveal `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestionReveal), &reveal)
+	assert.Equal(t, correct.CorrectIndex, reveal.Payload.CorrectIndex)
+
+	// the second question is skipped, which ends the game
+	command("next-2", server.PlayerCommandTypeNextQuestion)
+	readUntil(t, ws, server.GameEventTypeQuestion)
+	command("next-3", server.PlayerCommandTypeNextQuestion)
+
+	var end struct {
+		Payload server.GameEventEnd `json:"payload"`
+	}
+	json.Unmarshal(readUntil(t, ws, server.GameEventTypeEnd), &end)
+	assert.Equal(t, &captrivia.PracticeSummary{
+		Questions: 2,
+		Answered:  1,
+		Correct:   1,
+		Skipped:   1,
+		Accuracy:  1,
+		Score:     1,
+	}, end.Payload.Practice)
+
+	// the hub forgets the game once it is over
+	assert.Eventually(t, func() bool {
+		_, err := hub.GetGameHub(gameID)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGameHubDaily(t *testing.T) {
+	hub := server.NewHub(MockGameService{daily: &sync.Map{}}, testQuestionBank, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=solo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	daily := toBytes(server.PlayerCommand{Nonce: "daily", Type: server.PlayerCommandTypeDaily})
+	ws.WriteMessage(websocket.TextMessage, daily)
+	gameID := readCommandAck(t, ws).GameID
+
+	// the first question is answered and the rest are skipped
+	for i := range captrivia.DailyQuestionCount + 1 {
+		ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+			Nonce:   fmt.Sprintf("next-%d", i),
+			Payload: Raw(server.PlayerLobbyCommand{GameID: gameID}),
+			Type:    server.PlayerCommandTypeNextQuestion,
+		}))
+		if i > 0 {
+			continue
+		}
+		var question struct {
+			Payload server.GameEventQuestion `json:"payload"`
+		}
+		json.Unmarshal(readUntil(t, ws, server.GameEventTypeQuestion), &question)
+		for _, q := range testQuestionBank.Questions() {
+			if q.ID == question.Payload.ID {
+				ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{
+					Nonce:   "answer",
+					Payload: Raw(server.PlayerCommandAnswer{GameID: gameID, Index: q.CorrectIndex, QuestionID: q.ID}),
+					Type:    server.PlayerCommandTypeAnswer,
+				}))
+			}
+		}
+		readUntil(t, ws, server.GameEventTypeQuestionReveal)
+	}
+	readUntil(t, ws, server.GameEventTypeEnd)
+
+	// each day's challenge is played once
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{Nonce: "again", Type: server.PlayerCommandTypeDaily}))
+	assert.Equal(t, server.CommandErrorAlreadyPlayed, readCommandError(t, ws).Code)
+
+	resp, err := http.Get(s.URL + "/daily/leaderboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var leaderboard []captrivia.DailyResult
+	json.NewDecoder(resp.Body).Decode(&leaderboard)
+	if assert.Len(t, leaderboard, 1) {
+		assert.Equal(t, "solo", leaderboard[0].PlayerName)
+		assert.Equal(t, 1, leaderboard[0].Rank)
+		assert.Equal(t, 1, leaderboard[0].Correct)
+		assert.Equal(t, captrivia.DailyDate(time.Now()), leaderboard[0].Date)
+	}
+}
+
+func TestGameHubDailyLeftBeforeStarting(t *testing.T) {
+	hub := server.NewHub(MockGameService{daily: &sync.Map{}}, testQuestionBank, 1, 3)
+	hub.ReconnectGrace = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	s := httptest.NewServer(server.NewRouter(server.NewGameServer(hub), nil))
+	defer s.Close()
+
+	ws, _, err := dialConnect(s, "name=solo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.WriteMessage(websocket.TextMessage, toBytes(server.PlayerCommand{Nonce: "daily", Type: server.PlayerCommandTypeDaily}))
+	readCommandAck(t, ws)
+	ws.Close()
+	time.Sleep(300 * time.Millisecond)
+
+	// leaving before the first question still uses up the day's challenge,
+	// and is recorded as a result
+	resp, err := http.Get(s.URL + "/daily/leaderboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var leaderboard []captrivia.DailyResult
+	json.NewDecoder(resp.Body).Decode(&leaderboard)
+	if assert.Len(t, leaderboard, 1) {
+		assert.Equal(t, "solo", leaderboard[0].PlayerName)
+		assert.Equal(t, 0, leaderboard[0].Answered)
+	}
+}
+
+func TestNewDailyGameHubOnce(t *testing.T) {
+	hub := server.NewHub(MockGameService{daily: &sync.Map{}}, testQuestionBank, 1, 3)
+
+	// only one of the daily games started at the same time gets the slot
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := hub.NewDailyGameHub("solo")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	started := 0
+	for err := range errs {
+		if err == nil {
+			started++
+			continue
+		}
+		assert.ErrorIs(t, err, captrivia.ErrDailyPlayed)
+	}
+	assert.Equal(t, 1, started)
+}
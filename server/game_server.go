@@ -2,14 +2,22 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+)
+
 type GameServer struct {
 	hub *Hub
 }
@@ -19,11 +27,21 @@ func NewGameServer(hub *Hub) *GameServer {
 }
 
 type HttpGameResp struct {
-	ID            uuid.UUID           `json:"id"`
-	Name          string              `json:"name"`
-	PlayerCount   int                 `json:"player_count"`
-	QuestionCount int                 `json:"question_count"`
-	State         captrivia.GameState `json:"state"`
+	ID               uuid.UUID             `json:"id"`
+	Name             string                `json:"name"`
+	PlayerCount      int                   `json:"player_count"`
+	QuestionCount    int                   `json:"question_count"`
+	Mode             captrivia.GameMode    `json:"mode"`
+	QuestionSeconds  int                   `json:"question_seconds"`
+	CountdownSeconds int                   `json:"countdown_seconds"`
+	RevealSeconds    int                   `json:"reveal_seconds"`
+	MaxPlayers       int                   `json:"max_players"`
+	ScoringMode      captrivia.ScoringMode `json:"scoring_mode"`
+	Categories       []string              `json:"categories"`
+	Private          bool                  `json:"private"`
+	HasPassword      bool                  `json:"has_password"`
+	Full             bool                  `json:"full"` // new players can only spectate
+	State            captrivia.GameState   `json:"state"`
 }
 
 func GameToHTTPResp(g captrivia.RepositoryGame) HttpGameResp {
@@ -32,6 +50,16 @@ func GameToHTTPResp(g captrivia.RepositoryGame) HttpGameResp {
 		g.Name,
 		g.PlayerCount,
 		g.QuestionCount,
+		g.Mode,
+		g.QuestionSeconds,
+		g.CountdownSeconds,
+		g.RevealSeconds,
+		g.MaxPlayers,
+		g.ScoringMode,
+		g.Categories,
+		g.Private,
+		g.HasPassword,
+		g.MaxPlayers > 0 && g.PlayerCount >= g.MaxPlayers,
 		g.State,
 	}
 }
@@ -49,15 +77,101 @@ func (g *GameServer) Games(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	for _, g := range games {
+		// private games are only found with their invite code, practice
+		// games are only seen by their player
+		if g.Private || g.Mode == captrivia.GameModePractice {
+			continue
+		}
 		httpGames = append(httpGames, GameToHTTPResp(g))
 	}
-	if len(games) < 1 {
+	if len(httpGames) < 1 {
 		writeJSON(w, http.StatusNoContent, httpGames)
+		return
 	}
 	writeJSON(w, http.StatusOK, httpGames)
 }
 
+// Invite writes the game an invite code is for to the response, so a player
+// can see what they are joining before they join.
+func (g *GameServer) Invite(w http.ResponseWriter, r *http.Request) {
+	code := captrivia.NormalizeInviteCode(r.PathValue("code"))
+
+	id, err := g.hub.GameService.GetInviteCodeGame(code)
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not look up invite code"})
+		return
+	}
+	if id == uuid.Nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no game found for invite code"})
+		return
+	}
+
+	// the game is usually hosted here, otherwise it is found among the saved
+	// games
+	if gh, err := g.hub.GetGameHub(id); err == nil {
+		writeJSON(w, http.StatusOK, GameToHTTPResp(gh.game.ToRepositoryGame()))
+		return
+	}
+	games, err := g.hub.GameService.GetGames()
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not look up invite code"})
+		return
+	}
+	for _, game := range games {
+		if game.ID == id {
+			writeJSON(w, http.StatusOK, GameToHTTPResp(game))
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "no game found for invite code"})
+}
+
+// GameAnswers writes the answer log of a finished game to the response.
+func (g *GameServer) GameAnswers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid game id"})
+		return
+	}
+
+	if gh, err := g.hub.GetGameHub(id); err == nil && !gh.game.State.Finished() {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "game has not ended"})
+		return
+	}
+
+	answers, err := g.hub.GameService.GetAnswerLog(id)
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, []captrivia.AnswerRecord{})
+		return
+	}
+	if answers == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no answers found for game"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, answers)
+}
+
+// Connect upgrades the request to a websocket for a new player with the name
+// query param, or for a player reconnecting with their session token.
 func (g *GameServer) Connect(w http.ResponseWriter, r *http.Request) {
+	if token := r.URL.Query().Get("token"); token != "" {
+		c, ok := g.hub.Session(token)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !c.detached.Load() {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		c.Reconnect(w, r)
+		return
+	}
+
 	name := r.URL.Query().Get("name")
 	if name == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -65,7 +179,7 @@ func (g *GameServer) Connect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// fail if user already exists with name
-	if _, ok := g.hub.clientNames[name]; ok {
+	if g.hub.NameTaken(name) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -73,26 +187,151 @@ func (g *GameServer) Connect(w http.ResponseWriter, r *http.Request) {
 	c.ServeWebsocket(w, r)
 }
 
+// Leaderboard writes a page of player stats to the response. The page can be
+// controlled with the sort, page and page_size query params.
 func (g *GameServer) Leaderboard(w http.ResponseWriter, r *http.Request) {
-	t, err := time.Parse(time.RFC3339, "2022-01-01T12:00:00Z")
+	query, err := leaderboardQuery(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	leaderboard, err := g.hub.GameService.GetLeaderboard(query)
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, []captrivia.PlayerStats{})
+		return
+	}
+	if leaderboard == nil {
+		leaderboard = []captrivia.PlayerStats{}
+	}
+
+	writeJSON(w, http.StatusOK, leaderboard)
+}
+
+// Status writes the node ID and the active question bank version to the
+// response.
+func (g *GameServer) Status(w http.ResponseWriter, r *http.Request) {
+	version, err := g.hub.QuestionBank.Version()
 	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not get question bank version"})
 		return
 	}
-	leaderboard := []struct {
-		PlayerName          string    `json:"player_name"`
-		Accuracy            float32   `json:"accuracy"`
-		AverageMilliseconds int       `json:"average_milliseconds"`
-		CorrectQuestions    string    `json:"correct_questions"`
-		TimeMilliseconds    string    `json:"time_milliseconds"`
-		TotalQuestions      string    `json:"total_questions"`
-		LastUpdate          time.Time `json:"last_update"`
-	}{
-		{"John Doe", 0.75, 1500, "10", "5000", "15", t},
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"node_id":               g.hub.NodeID,
+		"question_bank_version": version,
+	})
+}
+
+// Daily writes the daily challenge to the response, today's unless the date
+// query param asks for an earlier day's. A challenge no one has started is
+// not found.
+func (g *GameServer) Daily(w http.ResponseWriter, r *http.Request) {
+	date, err := dailyDate(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	challenge, err := captrivia.NewDailyChallenge(date, g.hub.QuestionBank, g.hub.GameService)
+	if errors.Is(err, captrivia.ErrDailyNotStarted) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not draw the daily challenge"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, challenge)
+}
+
+// DailyLeaderboard writes a page of the ranked results of the daily challenge
+// to the response. The day and page can be controlled with the date, page and
+// page_size query params.
+func (g *GameServer) DailyLeaderboard(w http.ResponseWriter, r *http.Request) {
+	date, err := dailyDate(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	offset, limit, err := pageQuery(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	leaderboard, err := g.hub.GameService.GetDailyLeaderboard(date, offset, limit)
+	if err != nil {
+		log.Println(err)
+		writeJSON(w, http.StatusInternalServerError, []captrivia.DailyResult{})
+		return
+	}
+	if leaderboard == nil {
+		leaderboard = []captrivia.DailyResult{}
 	}
 
 	writeJSON(w, http.StatusOK, leaderboard)
 }
 
+func dailyDate(r *http.Request) (string, error) {
+	now := time.Now()
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return captrivia.DailyDate(now), nil
+	}
+	return captrivia.ParseDailyDate(date, now)
+}
+
+func leaderboardQuery(r *http.Request) (captrivia.LeaderboardQuery, error) {
+	query := captrivia.LeaderboardQuery{
+		Sort: captrivia.LeaderboardSortAccuracy,
+	}
+
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		query.Sort = captrivia.LeaderboardSort(sort)
+		if err := query.Sort.Validate(); err != nil {
+			return query, err
+		}
+	}
+	offset, limit, err := pageQuery(r)
+	if err != nil {
+		return query, err
+	}
+	query.Offset = offset
+	query.Limit = limit
+
+	return query, nil
+}
+
+// pageQuery returns the offset and limit of the page asked for with the page
+// and page_size query params.
+func pageQuery(r *http.Request) (int, int, error) {
+	page := 1
+	limit := defaultPageSize
+
+	params := r.URL.Query()
+	if p := params.Get("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+		page = n
+	}
+	if ps := params.Get("page_size"); ps != "" {
+		n, err := strconv.Atoi(ps)
+		if err != nil || n < 1 || n > maxPageSize {
+			return 0, 0, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+		limit = n
+	}
+
+	return (page - 1) * limit, limit, nil
+}
+
 func writeJSON(w http.ResponseWriter, statusCode int, obj any) error {
 	b, err := json.Marshal(obj)
 	if err != nil {
@@ -0,0 +1,193 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+	"github.com/dylanconnolly/captrivia-be/server"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboard(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/leaderboard?sort=correct&page=1&page_size=1", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []map[string]any
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp))
+	assert.Equal(t, "player 1", resp[0]["player_name"])
+	assert.Equal(t, "3", resp[0]["correct_questions"])
+	assert.Equal(t, 0.75, resp[0]["accuracy"])
+	assert.Equal(t, float64(1000), resp[0]["average_milliseconds"])
+}
+
+func TestLeaderboardEmptyPage(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/leaderboard?page=5", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "[]", rec.Body.String())
+}
+
+func TestLeaderboardBadQuery(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	for _, query := range []string{"sort=fastest", "page=0", "page_size=1000", "page=abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/leaderboard?"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGameAnswers(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/"+uuid.NewString()+"/answers", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []captrivia.AnswerRecord
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(resp))
+	assert.Equal(t, "player 1", resp[0].Player)
+	assert.Equal(t, int64(1200), resp[0].LatencyMs)
+}
+
+func TestGameAnswersNotFound(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/"+missingGameID.String()+"/answers", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/games/not-a-uuid/answers", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGameAnswersGameInProgress(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/games/"+gh.ID.String()+"/answers", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestGamesHidesPrivateGames(t *testing.T) {
+	hub := server.NewHub(MockGameService{}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/games", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []server.HttpGameResp
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, len(resp))
+	assert.Equal(t, "test game", resp[0].Name)
+}
+
+func TestInvite(t *testing.T) {
+	hub := server.NewHub(MockGameService{invites: &sync.Map{}}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	private := true
+	gh, err := hub.NewGameHub(gameName, captrivia.GameSettings{QuestionCount: questionCount, Private: &private})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// codes are looked up whatever case they are typed in
+	req := httptest.NewRequest(http.MethodGet, "/invites/"+strings.ToLower(gh.InviteCode()), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp server.HttpGameResp
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, gh.ID, resp.ID)
+	assert.True(t, resp.Private)
+
+	req = httptest.NewRequest(http.MethodGet, "/invites/ZZZZZZ", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDaily(t *testing.T) {
+	hub := server.NewHub(MockGameService{daily: &sync.Map{}}, testQuestionBank, 1, 1)
+	router := server.NewRouter(server.NewGameServer(hub), nil)
+
+	// describing a challenge no one has started doesn't pin its questions
+	for _, query := range []string{"", "?date=2024-05-01", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/daily"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code, query)
+	}
+
+	if _, err := hub.NewDailyGameHub("solo"); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/daily", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var challenge captrivia.DailyChallenge
+	err := json.Unmarshal(rec.Body.Bytes(), &challenge)
+	assert.NoError(t, err)
+	assert.Equal(t, captrivia.DailyDate(time.Now()), challenge.Date)
+	assert.Equal(t, captrivia.DailyQuestionCount, challenge.QuestionCount)
+	assert.NotContains(t, rec.Body.String(), "correctIndex")
+
+	// later days' challenges aren't known yet
+	tomorrow := captrivia.DailyDate(time.Now().Add(24 * time.Hour))
+	for _, query := range []string{"date=" + tomorrow, "date=may", "page=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/daily/leaderboard?"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
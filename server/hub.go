@@ -2,14 +2,24 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/dylanconnolly/captrivia-be/captrivia"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultReconnectGrace = 30 * time.Second
+	defaultRevealSec      = 3
+
+	// invite codes are drawn again when they clash with another game's
+	maxInviteCodeAttempts = 10
+)
+
 // Hub is the top level struct tracking all active clients.
 // It is responsible for
 type Hub struct {
@@ -18,48 +28,89 @@ type Hub struct {
 	clients      map[*Client]bool // tracks all active clients
 	clientNames  map[string]bool
 	disconnect   chan *Client
+	expire       chan *Client     // clients whose reconnect grace window may have passed
 	hubClients   map[*Client]bool // tracks only clients that are in the hub (not in a game)
-	mu           sync.Mutex
+	mu           sync.Mutex       // guards clientNames, sessions and gameHubs
+	reconnect    chan *Client
 	register     chan *Client
+	sessions     map[string]*Client // session token -> client, including detached clients
 	unregister   chan *Client
 
+	// ReconnectGrace is how long a disconnected client keeps its name and game
+	// seat while waiting to reconnect with its session token
+	ReconnectGrace time.Duration
+
+	// broadcast fields
+	Bus     Bus                // fans broadcasts out to every server instance
+	NodeID  string             // identifies this server instance on the Bus
+	proxies map[string]*Client // players connected to other nodes that sent commands for games on this node, by proxyKey
+
 	// game fields
 	GameService  captrivia.GameService
-	gameHubs     map[uuid.UUID]*GameHub
-	hubBroadcast chan GameEvent // used to broadcast GameEvents to clients not in games (GameCreate, GameStateChange, GamePlayerCountChange)
+	QuestionBank captrivia.QuestionBank // loaded once at startup, games draw their questions from it
+	gameHubs     map[uuid.UUID]*GameHub // running games, a game is removed once its GameHub stops
+	hubBroadcast chan GameEvent         // used to broadcast GameEvents to clients not in games (GameCreate, GameStateChange, GamePlayerCountChange)
 	CountdownSec int
 	QuestionSec  int
+	RevealSec    int // zero skips the reveal phase of games that don't choose their own, games skip it with captrivia.NoReveal
 }
 
-func NewHub(gs captrivia.GameService, countdownSec int, questionSec int) *Hub {
+func NewHub(gs captrivia.GameService, bank captrivia.QuestionBank, countdownSec int, questionSec int) *Hub {
 	return &Hub{
 		allBroadcast: make(chan []byte, 100),
 		clients:      make(map[*Client]bool),
 		clientNames:  make(map[string]bool),
 		disconnect:   make(chan *Client),
+		expire:       make(chan *Client, 10),
 		hubClients:   make(map[*Client]bool),
+		reconnect:    make(chan *Client, 10),
 		register:     make(chan *Client, 10),
+		sessions:     make(map[string]*Client),
 		unregister:   make(chan *Client, 10),
 
+		ReconnectGrace: defaultReconnectGrace,
+
+		Bus:     NewMemoryBus(),
+		NodeID:  uuid.NewString(),
+		proxies: make(map[string]*Client),
+
 		GameService:  gs,
+		QuestionBank: bank,
 		gameHubs:     make(map[uuid.UUID]*GameHub),
 		hubBroadcast: make(chan GameEvent, 25),
 		CountdownSec: countdownSec,
 		QuestionSec:  questionSec,
+		RevealSec:    defaultRevealSec,
 	}
 }
 
 func (h *Hub) Run(ctx context.Context) {
+	allMessages, err := h.Bus.Subscribe(ctx, topicAll)
+	if err != nil {
+		log.Printf("error subscribing hub to bus: %s", err)
+		return
+	}
+	lobbyMessages, err := h.Bus.Subscribe(ctx, topicLobby)
+	if err != nil {
+		log.Printf("error subscribing hub to bus: %s", err)
+		return
+	}
+	nodeMessages, err := h.Bus.Subscribe(ctx, fmt.Sprintf(topicNode, h.NodeID))
+	if err != nil {
+		log.Printf("error subscribing hub to bus: %s", err)
+		return
+	}
+
 	for {
 		select {
 		case client := <-h.register:
-			h.hubClients[client] = true
-			h.clients[client] = true
-			h.clientNames[client.name] = true
+			h.registerClient(client)
 		case client := <-h.unregister:
 			// Unregister removes client from hubClients so they will not receieve GameEvent updates while in a game
 			delete(h.hubClients, client)
 		case message := <-h.allBroadcast:
+			h.publish(topicAll, message)
+		case message := <-allMessages:
 			for client := range h.clients {
 				select {
 				case client.Send <- message:
@@ -69,29 +120,65 @@ func (h *Hub) Run(ctx context.Context) {
 				}
 			}
 		case event := <-h.hubBroadcast:
+			h.publish(topicLobby, event.toBytes())
+		case message := <-lobbyMessages:
 			// send game state changes to clients which are not actively in a game
 			for client := range h.hubClients {
 				select {
-				case client.Send <- event.toBytes():
+				case client.Send <- message:
 				default:
 					close(client.Send)
 					delete(h.clients, client)
 				}
 			}
 		case client := <-h.disconnect:
-			if client.gameHub != nil {
-				client.gameHub.playerLeave(client)
-			}
 			h.mu.Lock()
 			delete(h.clients, client)
 			delete(h.hubClients, client)
-			delete(h.clientNames, client.name)
 			h.mu.Unlock()
-			client.mu.Lock()
-			if !client.closed {
-				close(client.Send)
+			if h.ReconnectGrace > 0 {
+				// hold on to the client's name and game seat until the grace
+				// window passes so it can reconnect with its session token
+				client.detached.Store(true)
+				client.detachedUntil = time.Now().Add(h.ReconnectGrace)
+				time.AfterFunc(h.ReconnectGrace, func() {
+					h.expire <- client
+				})
+			} else {
+				h.removeClient(client)
 			}
-			client.mu.Unlock()
+		case client := <-h.expire:
+			// the client may have reconnected, or disconnected again and started
+			// a new grace window, since this expiry was scheduled
+			if client.detached.Load() && !time.Now().Before(client.detachedUntil) {
+				log.Printf("reconnect grace expired for player %s", client.name)
+				h.removeClient(client)
+			}
+		case client := <-h.reconnect:
+			h.mu.Lock()
+			session, ok := h.sessions[client.token]
+			h.mu.Unlock()
+			if !ok || session != client {
+				log.Printf("player %s reconnected after their session expired", client.name)
+				client.Conn.Close()
+				continue
+			}
+			client.detached.Store(false)
+			h.clients[client] = true
+			if gh := client.gameHub.Load(); gh != nil {
+				// replays the game state to the client and lets the game know
+				// the player is back
+				gh.Register <- client
+			} else {
+				h.hubClients[client] = true
+			}
+		case message := <-nodeMessages:
+			// a client is queued for registering before it sends its first
+			// command, register it before handling replies to its commands
+			for len(h.register) > 0 {
+				h.registerClient(<-h.register)
+			}
+			h.handleNodeMessage(ctx, message)
 		case <-ctx.Done():
 			log.Println("stopping Hub goroutine.")
 			return
@@ -99,28 +186,376 @@ func (h *Hub) Run(ctx context.Context) {
 	}
 }
 
-func (h *Hub) NewGameHub(name string, questionCount int) (*GameHub, error) {
-	game, err := captrivia.NewGame(name, questionCount)
+func (h *Hub) registerClient(client *Client) {
+	if client.remoteNode != "" {
+		// proxies for players on other nodes only live in the games
+		// they join, their own node keeps them up to date with the lobby
+		return
+	}
+	h.hubClients[client] = true
+	h.clients[client] = true
+	h.mu.Lock()
+	h.clientNames[client.name] = true
+	h.sessions[client.token] = client
+	h.mu.Unlock()
+
+	// players reconnecting after a restart are put back in their game
+	if client.gameHub.Load() == nil {
+		for _, gh := range h.listGameHubs() {
+			if gh.awaitingPlayer(client.name) {
+				gh.Register <- client
+				break
+			}
+		}
+	}
+}
+
+func (h *Hub) publish(topic string, message []byte) {
+	if err := h.Bus.Publish(topic, message); err != nil {
+		log.Printf("error publishing to %s: %s", topic, err)
+	}
+}
+
+// handleNodeMessage handles envelopes routed to this node by other nodes,
+// either commands from their players for games hosted here or messages for
+// players connected here from games hosted there.
+func (h *Hub) handleNodeMessage(ctx context.Context, message []byte) {
+	var envelope nodeEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		log.Printf("error unmarshalling node message: %s", err)
+		return
+	}
+
+	switch envelope.Type {
+	case nodeEnvelopeCommand:
+		key := proxyKey(envelope.Node, envelope.Player)
+		proxy, ok := h.proxies[key]
+		if !ok {
+			proxy = newProxyClient(ctx, envelope.Player, envelope.Node, h)
+			h.proxies[key] = proxy
+		}
+		select {
+		case proxy.inbox <- envelope.Message:
+		default:
+			log.Printf("dropping command from remote player %s, inbox is full", envelope.Player)
+		}
+	case nodeEnvelopeMessage:
+		for client := range h.clients {
+			if client.name != envelope.Player {
+				continue
+			}
+			select {
+			case client.Send <- envelope.Message:
+			default:
+				log.Printf("dropping message for player %s, send buffer is full", client.name)
+			}
+		}
+	case nodeEnvelopeDisconnect:
+		key := proxyKey(envelope.Node, envelope.Player)
+		proxy, ok := h.proxies[key]
+		if !ok {
+			return
+		}
+		delete(h.proxies, key)
+		// the game removes the proxy on its own goroutine, the proxy stops
+		// relaying messages once its inbox is closed
+		close(proxy.inbox)
+		if gh := proxy.gameHub.Load(); gh != nil {
+			gh.Unregister <- proxy
+		}
+	}
+}
+
+// proxyKey identifies the proxy of a player connected to another node, names
+// are only unique on the node the player is connected to.
+func proxyKey(node string, player string) string {
+	return node + "/" + player
+}
+
+// isRemoteGame reports whether a game is hosted by another node.
+func (h *Hub) isRemoteGame(gameID uuid.UUID) (string, bool) {
+	if _, err := h.GetGameHub(gameID); err == nil {
+		return "", false
+	}
+	owner, err := h.GameService.GetGameOwner(gameID)
 	if err != nil {
+		log.Printf("error getting owner of gameID=%s: %s", gameID, err)
+		return "", false
+	}
+	return owner, owner != "" && owner != h.NodeID
+}
+
+// forwardCommand routes a command from a client connected to this node to
+// the node hosting the game it is for.
+func (h *Hub) forwardCommand(c *Client, node string, message []byte) {
+	c.forwardedTo[node] = true
+	envelope := nodeEnvelope{
+		Type:    nodeEnvelopeCommand,
+		Node:    h.NodeID,
+		Player:  c.name,
+		Message: message,
+	}
+	if err := publishToNode(h.Bus, node, envelope); err != nil {
+		log.Printf("error forwarding command from %s to node %s: %s", c.name, node, err)
+	}
+}
+
+// removeClient drops every reference the hub holds to a client, removing it
+// from its game and freeing up its name.
+func (h *Hub) removeClient(client *Client) {
+	if gh := client.gameHub.Load(); gh != nil {
+		gh.playerLeave(client)
+	}
+	h.mu.Lock()
+	delete(h.clients, client)
+	delete(h.hubClients, client)
+	delete(h.clientNames, client.name)
+	delete(h.sessions, client.token)
+	h.mu.Unlock()
+
+	// let the nodes hosting games the client sent commands to remove them
+	client.mu.Lock()
+	for node := range client.forwardedTo {
+		envelope := nodeEnvelope{Type: nodeEnvelopeDisconnect, Node: h.NodeID, Player: client.name}
+		if err := publishToNode(h.Bus, node, envelope); err != nil {
+			log.Printf("error notifying node %s of disconnect: %s", node, err)
+		}
+	}
+	client.mu.Unlock()
+
+	client.mu.Lock()
+	if !client.closed {
+		close(client.Send)
+	}
+	client.mu.Unlock()
+}
+
+// Session returns the client holding a session token.
+func (h *Hub) Session(token string) (*Client, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.sessions[token]
+	return c, ok
+}
+
+// NameTaken reports whether a client, connected or awaiting reconnection, is
+// using the name.
+func (h *Hub) NameTaken(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.clientNames[name]
+}
+
+// NewGameHub creates a game with the settings. The game is kept out of the
+// lobby until it is announced, so whoever creates it can take their seat first.
+func (h *Hub) NewGameHub(name string, settings captrivia.GameSettings) (*GameHub, error) {
+	if err := settings.Validate(); err != nil {
 		return nil, fmt.Errorf("error creating game for game hub: %s", err)
 	}
-	gh := NewGameHub(game, h.GameService, h.hubBroadcast, h.CountdownSec, h.QuestionSec)
-	h.gameHubs[gh.ID] = gh
+	settings = h.withDefaults(settings)
+	game, err := captrivia.NewGame(name, settings.QuestionCount, h.QuestionBank, settings.QuestionFilter)
+	if err != nil {
+		return nil, fmt.Errorf("error creating game for game hub: %s", err)
+	}
+	if err := game.ApplySettings(settings); err != nil {
+		return nil, fmt.Errorf("error creating game for game hub: %s", err)
+	}
+	if game.Private {
+		if game.InviteCode, err = h.reserveInviteCode(game.ID); err != nil {
+			return nil, fmt.Errorf("error creating game for game hub: %s", err)
+		}
+	}
+	gh := NewGameHub(game, h.GameService, h.hubBroadcast, game.CountdownSeconds, game.QuestionSeconds)
+	gh.questionBank = h.QuestionBank
+	h.addGameHub(gh)
+	if err := h.GameService.SetGameOwner(game.ID, h.NodeID); err != nil {
+		log.Printf("error setting owner of gameID=%s: %s", game.ID, err)
+	}
+	return gh, nil
+}
+
+// announceGame tells the lobby about a new game. Private games are kept out of
+// the lobby.
+func (h *Hub) announceGame(gh *GameHub) {
+	if gh.game.Private {
+		return
+	}
+	h.hubBroadcast <- newGameEventCreate(gh.game)
+}
+
+// NewPracticeGameHub creates a practice game for the player, drawing its
+// questions with the settings. Practice games are kept out of the lobby and
+// only update the leaderboard if they are ranked.
+func (h *Hub) NewPracticeGameHub(player string, settings captrivia.GameSettings, ranked bool) (*GameHub, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("error creating practice game for game hub: %s", err)
+	}
+	settings = settings.Practice()
+	game, err := captrivia.NewGame(player+" practice", settings.QuestionCount, h.QuestionBank, settings.QuestionFilter)
+	if err != nil {
+		return nil, fmt.Errorf("error creating practice game for game hub: %s", err)
+	}
+	if err := game.ApplySettings(settings); err != nil {
+		return nil, fmt.Errorf("error creating practice game for game hub: %s", err)
+	}
+	game.Ranked = ranked
 
-	ge := newGameEventCreate(game.ID, game.Name, game.QuestionCount)
-	h.hubBroadcast <- ge
+	gh := NewGameHub(game, h.GameService, h.hubBroadcast, game.CountdownSeconds, game.QuestionSeconds)
+	h.addGameHub(gh)
+	if err := h.GameService.SetGameOwner(game.ID, h.NodeID); err != nil {
+		log.Printf("error setting owner of gameID=%s: %s", game.ID, err)
+	}
 	return gh, nil
 }
 
+// NewDailyGameHub creates a practice game for the player with the questions
+// of today's daily challenge. ErrDailyPlayed is returned if the player has
+// already played or is playing today's challenge.
+func (h *Hub) NewDailyGameHub(player string) (*GameHub, error) {
+	date := captrivia.DailyDate(time.Now())
+	game, err := captrivia.NewDailyGame(player+" daily "+date, date, h.QuestionBank, h.GameService)
+	if err != nil {
+		return nil, fmt.Errorf("error creating daily game for game hub: %s", err)
+	}
+
+	// the player's slot is reserved in one step before the game is started,
+	// so two daily commands racing each other can't both start a game
+	reserved, err := h.GameService.ReserveDaily(date, player)
+	if err != nil {
+		return nil, fmt.Errorf("error reserving daily challenge: %s", err)
+	}
+	if !reserved {
+		return nil, fmt.Errorf("%w for %s", captrivia.ErrDailyPlayed, date)
+	}
+
+	gh := NewGameHub(game, h.GameService, h.hubBroadcast, game.CountdownSeconds, game.QuestionSeconds)
+	h.addGameHub(gh)
+	if err := h.GameService.SetGameOwner(game.ID, h.NodeID); err != nil {
+		log.Printf("error setting owner of gameID=%s: %s", game.ID, err)
+	}
+	return gh, nil
+}
+
+// reserveInviteCode finds an invite code no other game is using and reserves
+// it for the game.
+func (h *Hub) reserveInviteCode(gameID uuid.UUID) (string, error) {
+	for range maxInviteCodeAttempts {
+		code := captrivia.NewInviteCode()
+		ok, err := h.GameService.ReserveInviteCode(code, gameID)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("no free invite code after %d attempts", maxInviteCodeAttempts)
+}
+
+// withDefaults fills in the question count and timings a player left out of
+// the game settings with the server's defaults. A reveal_seconds of captrivia.NoReveal is kept,
+// the game skips the reveal phase.
+func (h *Hub) withDefaults(settings captrivia.GameSettings) captrivia.GameSettings {
+	if settings.QuestionCount == 0 {
+		settings.QuestionCount = captrivia.DefaultQuestionCount
+	}
+	if settings.CountdownSeconds == 0 {
+		settings.CountdownSeconds = h.CountdownSec
+	}
+	if settings.QuestionSeconds == 0 {
+		settings.QuestionSeconds = h.QuestionSec
+	}
+	if settings.RevealSeconds == 0 {
+		settings.RevealSeconds = h.RevealSec
+	}
+	return settings
+}
+
+// RestoreGames rehydrates a GameHub for every unfinished game found in the
+// GameService so that games in progress when the server stopped are resumed.
+func (h *Hub) RestoreGames(ctx context.Context) error {
+	snapshots, err := h.GameService.GetGameSnapshots()
+	if err != nil {
+		return fmt.Errorf("error getting game snapshots: %s", err)
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.State.Finished() {
+			continue
+		}
+		// games hosted by other nodes are resumed by their own node
+		owner, err := h.GameService.GetGameOwner(snapshot.ID)
+		if err != nil {
+			log.Printf("error getting owner of gameID=%s: %s", snapshot.ID, err)
+			continue
+		}
+		if owner != "" && owner != h.NodeID {
+			continue
+		}
+		game, err := captrivia.RestoreGame(snapshot)
+		if err != nil {
+			log.Printf("error restoring gameID=%s: %s", snapshot.ID, err)
+			continue
+		}
+
+		// games saved before they had their own timings use the defaults,
+		// practice games are untimed
+		if game.CountdownSeconds == 0 && !game.IsPractice() {
+			game.CountdownSeconds = h.CountdownSec
+		}
+		if game.QuestionSeconds == 0 && !game.IsPractice() {
+			game.QuestionSeconds = h.QuestionSec
+		}
+		if game.RevealSeconds == 0 && !game.IsPractice() {
+			game.RevealSeconds = h.RevealSec
+		}
+		gh := NewGameHub(game, h.GameService, h.hubBroadcast, game.CountdownSeconds, game.QuestionSeconds)
+		gh.questionBank = h.QuestionBank
+		h.addGameHub(gh)
+		go gh.Run(ctx)
+		log.Printf("restored gameID=%s in state %s", game.ID, game.State)
+	}
+
+	return nil
+}
+
 func (h *Hub) GetGameHub(gameID uuid.UUID) (*GameHub, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	if gh, ok := h.gameHubs[gameID]; ok {
 		return gh, nil
 	}
 	return nil, fmt.Errorf("no gamehub found for gameID=%s", gameID)
 }
 
+// addGameHub tracks the GameHub until it stops running, once the game is
+// over it is forgotten.
+func (h *Hub) addGameHub(gh *GameHub) {
+	h.mu.Lock()
+	h.gameHubs[gh.ID] = gh
+	h.mu.Unlock()
+
+	go func() {
+		<-gh.stopped
+		h.mu.Lock()
+		delete(h.gameHubs, gh.ID)
+		h.mu.Unlock()
+	}()
+}
+
+// listGameHubs returns the GameHubs of every running game.
+func (h *Hub) listGameHubs() []*GameHub {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hubs := make([]*GameHub, 0, len(h.gameHubs))
+	for _, gh := range h.gameHubs {
+		hubs = append(hubs, gh)
+	}
+	return hubs
+}
+
 func (h *Hub) CloseGameHub(gameID uuid.UUID) {
-	if gh, ok := h.gameHubs[gameID]; ok {
+	if gh, err := h.GetGameHub(gameID); err == nil {
 		for client := range gh.Clients {
 			gh.Unregister <- client
 			h.register <- client
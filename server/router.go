@@ -2,12 +2,29 @@ package server
 
 import "net/http"
 
-func NewRouter(gameServer *GameServer) *http.ServeMux {
+// NewRouter registers the game routes, and the admin routes when an
+// AdminServer is provided.
+func NewRouter(gameServer *GameServer, adminServer *AdminServer) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /games", gameServer.Games) // Get existing games
+	mux.HandleFunc("GET /games/{id}/answers", gameServer.GameAnswers)
+	mux.HandleFunc("GET /invites/{code}", gameServer.Invite) // Look up the private game an invite code is for
 	mux.HandleFunc("GET /connect", gameServer.Connect)
-	mux.HandleFunc("GET /leaderboard", gameServer.Connect)
+	mux.HandleFunc("GET /leaderboard", gameServer.Leaderboard)
+	mux.HandleFunc("GET /daily", gameServer.Daily) // Describe the daily challenge without its questions
+	mux.HandleFunc("GET /daily/leaderboard", gameServer.DailyLeaderboard)
+	mux.HandleFunc("GET /status", gameServer.Status)
+
+	if adminServer != nil {
+		mux.HandleFunc("GET /admin/questions", adminServer.Authorize(adminServer.Questions))
+		mux.HandleFunc("POST /admin/questions", adminServer.Authorize(adminServer.CreateQuestion))
+		mux.HandleFunc("POST /admin/questions/import", adminServer.Authorize(adminServer.ImportQuestions))
+		mux.HandleFunc("POST /admin/questions/reload", adminServer.Authorize(adminServer.ReloadQuestions))
+		mux.HandleFunc("GET /admin/questions/{id}", adminServer.Authorize(adminServer.Question))
+		mux.HandleFunc("PUT /admin/questions/{id}", adminServer.Authorize(adminServer.UpdateQuestion))
+		mux.HandleFunc("DELETE /admin/questions/{id}", adminServer.Authorize(adminServer.RetireQuestion))
+	}
 
 	return mux
 }
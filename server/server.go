@@ -6,8 +6,8 @@ import (
 	"github.com/rs/cors"
 )
 
-func NewHTTPServer(addr string, gameServer *GameServer) *http.Server {
-	mux := NewRouter(gameServer)
+func NewHTTPServer(addr string, gameServer *GameServer, adminServer *AdminServer) *http.Server {
+	mux := NewRouter(gameServer, adminServer)
 
 	httpStack := cors.AllowAll().Handler(mux)
 
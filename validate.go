@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/dylanconnolly/captrivia-be/captrivia"
+)
+
+// validateQuestions lints each questions file given as an argument and
+// returns the exit code: 1 when any file has errors (or warnings with
+// -strict), 2 for bad usage.
+func validateQuestions(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("validate-questions", flag.ContinueOnError)
+	fs.SetOutput(out)
+	strict := fs.Bool("strict", false, "exit non-zero on warnings as well as errors")
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: captrivia-be validate-questions [-strict] questions.json...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		issues, err := captrivia.LintQuestionsFile(path)
+		if err != nil {
+			fmt.Fprintf(out, "%s: error: %s\n", path, err)
+			failed = true
+			continue
+		}
+
+		var errCount, warnCount int
+		for _, issue := range issues {
+			fmt.Fprintf(out, "%s: %s\n", path, issue)
+			if issue.Severity == captrivia.LintError {
+				errCount++
+			} else {
+				warnCount++
+			}
+		}
+		fmt.Fprintf(out, "%s: %d errors, %d warnings\n", path, errCount, warnCount)
+
+		if errCount > 0 || (*strict && warnCount > 0) {
+			failed = true
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}